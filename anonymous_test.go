@@ -0,0 +1,90 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+// boiWithGC adds a gc function, so scripts can make Go free anything
+// that's no longer held before they go on
+func boiWithGC(boi *BoiInterpreter) {
+	boi.RegisterGoFunction("gc", func(
+		context *BoiContext, args []BoiVar,
+	) (BoiVar, error) {
+		runtime.GC()
+		return BoiVar{}, nil
+	})
+}
+
+func TestAnonymousFunctionsKeptByClosures(t *testing.T) {
+	// The function made in other is only stored in slot, which belongs
+	// to the scope that setter and getter closed over
+	boi, err := runBoi(t, `
+boi! scoping lexical boi
+oh make boi
+    boi: slot "" boi
+    oh "" v boi
+        boi: slot boi:v boi
+    BOI
+    boi: setter ret:exit boi
+    oh boi
+        boi: exit boi:slot boi
+    BOI
+    boi: getter ret:exit boi
+    boi: exit [list boi:setter boi:getter] boi
+BOI
+boi: pair [make] boi
+boi: set [list-get boi:pair [int 0]] boi
+boi: get [list-get boi:pair [int 1]] boi
+oh other boi
+    oh boi
+        boi: exit hello boi
+    BOI
+    boi! apply boi:set [list ret:exit] boi
+BOI
+boi! other boi
+boi! gc boi
+boi: f [boi:get] boi
+boi: result [boi:f] boi
+`, boiWithGC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := boi.context.Get("result"); string(result.data) != "hello" {
+		t.Errorf("result = %q, expected hello", result.data)
+	}
+}
+
+func TestAnonymousFunctionsFreedWithScope(t *testing.T) {
+	boi, err := runBoi(t, `
+boi! scoping lexical boi
+boi: kept "" boi
+foreach i [int 0] [int 100] boi
+    oh boi
+        boi: exit boi:i boi
+    BOI
+    boi: f ret:exit boi
+    boi? == [dec boi:i] 42 boi
+        boi: kept boi:f boi
+    BOI
+BOI
+boi! nyan "so the last pass isn't ret:exit" boi
+boi! gc boi
+boi: result [dec [boi:kept]] boi
+`, boiWithGC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := boi.context.Get("result"); string(result.data) != "42" {
+		t.Errorf("result = %q, expected 42", result.data)
+	}
+	live := 0
+	for _, p := range boi.context.Root().anonymous.functions {
+		if p.Value() != nil {
+			live++
+		}
+	}
+	if live != 1 {
+		t.Errorf("%d anonymous functions are still around, expected 1", live)
+	}
+}
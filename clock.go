@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// BoiClock is where an interpreter gets the time from. Embedders (and
+// tests) can use SetClock to give it one that doesn't follow the real
+// time; timeouts go by this clock too.
+type BoiClock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// BoiSystemClock is the real clock, and the default
+type BoiSystemClock struct{}
+
+func (BoiSystemClock) Now() time.Time        { return time.Now() }
+func (BoiSystemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+func (boi *BoiInterpreter) SetClock(clock BoiClock) {
+	boi.clock = clock
+}
+
+// Times are integers counting seconds since 1970 (UTC), and durations are
+// integers counting nanoseconds. Since the units are different, times and
+// durations are mixed using time-add, time-sub and time-diff rather than
+// + and -.
+
+// boiVarToInt64 reads a time or a duration. It's like boiVarToIndex, but
+// always 64 bits, as an int can be too small to hold nanoseconds.
+func boiVarToInt64(v BoiVar) (int64, error) {
+	value, err := boiVarToInt(v)
+	if err != nil {
+		return 0, err
+	}
+	if !value.IsInt64() {
+		return 0, errors.New("integer is too big, boi")
+	}
+	return value.Int64(), nil
+}
+
+// boiInt64ToVar is the opposite of boiVarToInt64
+func boiInt64ToVar(i int64) BoiVar {
+	return BoiVar{big.NewInt(i).Bytes()}
+}
+
+// boiTimeLayouts are names that can be used instead of a layout. Other
+// layouts are written the way Go writes them, as the time
+// "2006-01-02 15:04:05".
+var boiTimeLayouts = map[string]string{
+	"RFC3339":  time.RFC3339,
+	"RFC1123":  time.RFC1123,
+	"DateTime": time.DateTime,
+	"DateOnly": time.DateOnly,
+	"TimeOnly": time.TimeOnly,
+	"Kitchen":  time.Kitchen,
+}
+
+func boiTimeLayout(v BoiVar) string {
+	if layout, exists := boiTimeLayouts[string(v.data)]; exists {
+		return layout
+	}
+	return string(v.data)
+}
+
+// BoiFuncNow returns the time according to the interpreter's clock, in
+// seconds (now) or nanoseconds (now-nanos) since 1970
+type BoiFuncNow struct {
+	interpreter *BoiInterpreter
+	unit        time.Duration
+}
+
+func (f BoiFuncNow) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("now doesn't take parameters")
+	}
+	now := f.interpreter.clock.Now().UnixNano()
+	return boiInt64ToVar(now / int64(f.unit)), nil
+}
+
+// BoiFuncTimeFormat writes a time using a layout, in UTC unless a time
+// zone (ex: America/Toronto) is given
+func BoiFuncTimeFormat(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return BoiVar{}, errors.New("time-format requires 2 or 3 parameters")
+	}
+	seconds, err := boiVarToInt64(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	location := time.UTC
+	if len(args) > 2 {
+		if location, err = time.LoadLocation(string(args[2].data)); err != nil {
+			return BoiVar{}, fmt.Errorf("time-format: %v", err)
+		}
+	}
+	t := time.Unix(seconds, 0).In(location)
+	return BoiVar{[]byte(t.Format(boiTimeLayout(args[0])))}, nil
+}
+
+// BoiFuncTimeParse reads a time written using a layout
+func BoiFuncTimeParse(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("time-parse requires 2 parameters")
+	}
+	t, err := time.Parse(boiTimeLayout(args[0]), string(args[1].data))
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("time-parse: %v", err)
+	}
+	if t.Unix() < 0 {
+		return BoiVar{}, errors.New("time-parse: times before 1970 aren't supported")
+	}
+	return boiInt64ToVar(t.Unix()), nil
+}
+
+// BoiFuncTimeAdd adds a duration to a time
+func BoiFuncTimeAdd(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("time-add requires 2 parameters")
+	}
+	seconds, err := boiVarToInt64(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	nanos, err := boiVarToInt64(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	t := time.Unix(seconds, 0).Add(time.Duration(nanos))
+	return boiInt64ToVar(t.Unix()), nil
+}
+
+// BoiFuncTimeSub takes a duration away from a time
+func BoiFuncTimeSub(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("time-sub requires 2 parameters")
+	}
+	seconds, err := boiVarToInt64(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	nanos, err := boiVarToInt64(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	t := time.Unix(seconds, 0).Add(-time.Duration(nanos))
+	if t.Unix() < 0 {
+		return BoiVar{}, errors.New("time-sub: times before 1970 aren't supported")
+	}
+	return boiInt64ToVar(t.Unix()), nil
+}
+
+// BoiFuncTimeDiff returns how far apart two times are, as a duration.
+// Like -, it doesn't matter which one is first.
+func BoiFuncTimeDiff(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("time-diff requires 2 parameters")
+	}
+	a, err := boiVarToInt64(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	b, err := boiVarToInt64(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	d := time.Unix(a, 0).Sub(time.Unix(b, 0))
+	if d < 0 {
+		d = -d
+	}
+	return boiInt64ToVar(int64(d)), nil
+}
+
+// BoiFuncDuration reads a duration like 1h30m or 250ms
+func BoiFuncDuration(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("duration requires 1 parameter")
+	}
+	d, err := time.ParseDuration(string(args[0].data))
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("duration: %v", err)
+	}
+	if d < 0 {
+		return BoiVar{}, errors.New("duration can't be negative")
+	}
+	return boiInt64ToVar(int64(d)), nil
+}
+
+// BoiFuncDurationFormat is the opposite of BoiFuncDuration
+func BoiFuncDurationFormat(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("duration-format requires 1 parameter")
+	}
+	nanos, err := boiVarToInt64(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	return BoiVar{[]byte(time.Duration(nanos).String())}, nil
+}
+
+// BoiFuncSleep waits for a duration. If the script would run out of time
+// first, it only waits that long, and then fails.
+type BoiFuncSleep struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncSleep) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("sleep requires 1 parameter")
+	}
+	nanos, err := boiVarToInt64(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	d := time.Duration(nanos)
+	boi := f.interpreter
+	if !boi.deadline.IsZero() {
+		if left := boi.deadline.Sub(boi.clock.Now()); left < d {
+			if left > 0 {
+				boi.clock.Sleep(left)
+			}
+			return BoiVar{}, fmt.Errorf("sleep: %v", boi.timedOut())
+		}
+	}
+	boi.clock.Sleep(d)
+	return BoiVar{}, nil
+}
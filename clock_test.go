@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// frozenClock never moves, and sleeping on it returns straight away
+type frozenClock struct{}
+
+func (frozenClock) Now() time.Time        { return time.Unix(0, 0) }
+func (frozenClock) Sleep(d time.Duration) {}
+
+func TestSleepPastTimeoutWithFrozenClock(t *testing.T) {
+	_, err := runBoi(t, "boi! sleep [duration 1m] boi\n",
+		func(boi *BoiInterpreter) {
+			boi.SetClock(frozenClock{})
+			boi.SetTimeout(time.Second)
+		},
+	)
+	if err == nil || !strings.Contains(err.Error(), "sleep: timed out after 1s") {
+		t.Errorf("expected sleep to time out, got %v", err)
+	}
+}
+
+func TestTimeArithmetic(t *testing.T) {
+	boi, err := runBoi(t, `
+boi: start [time-parse DateTime "2024-05-01 12:00:00"] boi
+boi: later [time-add boi:start [duration 1h30m]] boi
+boi: back [time-format DateTime [time-sub boi:later [duration 30m]]] boi
+boi: diff [duration-format [time-diff boi:start boi:later]] boi
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back, _ := boi.context.Get("back"); string(back.data) != "2024-05-01 13:00:00" {
+		t.Errorf("time-sub gave %s", back.data)
+	}
+	if diff, _ := boi.context.Get("diff"); string(diff.data) != "1h30m0s" {
+		t.Errorf("time-diff gave %s", diff.data)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Functions in this file compare values byte-for-byte and always return
+// the canonical "true" or "false" values.
+
+// BoiFuncEqual returns true if all parameters are exactly the same bytes
+func BoiFuncEqual(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 2 {
+		return BoiVar{}, errors.New("== requires at least 2 parameters")
+	}
+	for _, arg := range args[1:] {
+		if !bytes.Equal(args[0].data, arg.data) {
+			return boiBool(false), nil
+		}
+	}
+	return boiBool(true), nil
+}
+
+// BoiFuncNotEqual is the opposite of ==. It's called /= because a token
+// starting with ! is a function call.
+func BoiFuncNotEqual(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("/= requires 2 parameters")
+	}
+	return boiBool(!bytes.Equal(args[0].data, args[1].data)), nil
+}
+
+// BoiFuncBefore returns true if each parameter sorts before the next one,
+// comparing bytes lexicographically (like < does for integers)
+func BoiFuncBefore(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 2 {
+		return BoiVar{}, errors.New("before requires at least 2 parameters")
+	}
+	for i := 0; i < len(args)-1; i++ {
+		if bytes.Compare(args[i].data, args[i+1].data) >= 0 {
+			return boiBool(false), nil
+		}
+	}
+	return boiBool(true), nil
+}
+
+// BoiFuncAfter returns true if each parameter sorts after the next one
+func BoiFuncAfter(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 2 {
+		return BoiVar{}, errors.New("after requires at least 2 parameters")
+	}
+	for i := 0; i < len(args)-1; i++ {
+		if bytes.Compare(args[i].data, args[i+1].data) <= 0 {
+			return boiBool(false), nil
+		}
+	}
+	return boiBool(true), nil
+}
+
+func BoiFuncHasPrefix(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("has-prefix requires 2 parameters")
+	}
+	return boiBool(bytes.HasPrefix(args[0].data, args[1].data)), nil
+}
+
+func BoiFuncHasSuffix(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("has-suffix requires 2 parameters")
+	}
+	return boiBool(bytes.HasSuffix(args[0].data, args[1].data)), nil
+}
+
+func BoiFuncContains(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("contains requires 2 parameters")
+	}
+	return boiBool(bytes.Contains(args[0].data, args[1].data)), nil
+}
+
+func BoiFuncNot(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("not requires 1 parameter")
+	}
+	return boiBool(!args[0].IsTrue()), nil
+}
+
+// BoiFuncAnd returns true if every parameter is true
+func BoiFuncAnd(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	for _, arg := range args {
+		if !arg.IsTrue() {
+			return boiBool(false), nil
+		}
+	}
+	return boiBool(true), nil
+}
+
+// BoiFuncOr returns true if any parameter is true
+func BoiFuncOr(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	for _, arg := range args {
+		if arg.IsTrue() {
+			return boiBool(true), nil
+		}
+	}
+	return boiBool(false), nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// A dict is encoded like a list (see lists.go), but with its own magic
+// prefix and with keys and values alternating. Entries are always stored
+// sorted by key, so two dicts with the same contents have the same bytes
+// and == works on them.
+var boiDictMagic = []byte("\x00boi:dict\x00")
+
+func boiIsDict(v BoiVar) bool {
+	return bytes.HasPrefix(v.data, boiDictMagic)
+}
+
+func boiDictToVar(dict map[string]BoiVar) BoiVar {
+	keys := boiSortedKeys(dict)
+	entries := []BoiVar{}
+	for _, key := range keys {
+		entries = append(entries, BoiVar{[]byte(key)}, dict[key])
+	}
+	list := boiListToVar(entries)
+	data := append([]byte{}, boiDictMagic...)
+	data = append(data, list.data[len(boiListMagic):]...)
+	return BoiVar{data}
+}
+
+func boiVarToDict(v BoiVar) (map[string]BoiVar, error) {
+	if !boiIsDict(v) {
+		return nil, errors.New("value is not a dict")
+	}
+	entries, err := boiDecodeEntries(v.data[len(boiDictMagic):])
+	if err != nil || len(entries)%2 != 0 {
+		return nil, errors.New("corrupted dict, boi")
+	}
+	dict := map[string]BoiVar{}
+	for i := 0; i < len(entries); i += 2 {
+		dict[string(entries[i].data)] = entries[i+1]
+	}
+	return dict, nil
+}
+
+func boiSortedKeys(dict map[string]BoiVar) []string {
+	keys := []string{}
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// boiDisplayDict prints a dict as {key: value, ...} in key order
+func boiDisplayDict(v BoiVar) []byte {
+	dict, err := boiVarToDict(v)
+	if err != nil {
+		return v.data
+	}
+	output := []byte("{")
+	for i, key := range boiSortedKeys(dict) {
+		if i > 0 {
+			output = append(output, ", "...)
+		}
+		output = append(output, key...)
+		output = append(output, ": "...)
+		output = append(output, boiDisplay(dict[key])...)
+	}
+	return append(output, '}')
+}
+
+// boiGetDictVar looks up a variable that should hold a dict, for the
+// functions which change a dict in place. A variable that doesn't exist
+// yet is an empty dict.
+func boiGetDictVar(
+	context *BoiContext, name string,
+) (map[string]BoiVar, error) {
+	v, exists := context.parentCtx.Get(name)
+	if !exists {
+		return map[string]BoiVar{}, nil
+	}
+	dict, err := boiVarToDict(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return dict, nil
+}
+
+// BoiFuncDict makes a dict out of alternating keys and values
+func BoiFuncDict(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args)%2 != 0 {
+		return BoiVar{}, errors.New("dict requires pairs of keys and values")
+	}
+	dict := map[string]BoiVar{}
+	for i := 0; i < len(args); i += 2 {
+		dict[string(args[i].data)] = args[i+1]
+	}
+	return boiDictToVar(dict), nil
+}
+
+// BoiFuncDictPut stores a value under a key in the dict stored in the
+// named variable, and returns the new dict.
+func BoiFuncDictPut(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 3 {
+		return BoiVar{}, errors.New("dict-put requires 3 parameters")
+	}
+	name := string(args[0].data)
+	dict, err := boiGetDictVar(context, name)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	dict[string(args[1].data)] = args[2]
+	output := boiDictToVar(dict)
+	context.parentCtx.Set(name, output)
+	return output, nil
+}
+
+// BoiFuncDictGet returns the value stored under a key. Like icanhas, it
+// sets "exists" so a missing key can be told apart from an empty value.
+func BoiFuncDictGet(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("dict-get requires 2 parameters")
+	}
+	dict, err := boiVarToDict(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	value, exists := dict[string(args[1].data)]
+	context.variables["exists"] = boiBool(exists)
+	return value, nil
+}
+
+func BoiFuncDictHas(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("dict-has requires 2 parameters")
+	}
+	dict, err := boiVarToDict(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	_, exists := dict[string(args[1].data)]
+	return boiBool(exists), nil
+}
+
+// BoiFuncDictDelete removes a key from the dict stored in the named
+// variable, and returns the new dict.
+func BoiFuncDictDelete(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("dict-delete requires 2 parameters")
+	}
+	name := string(args[0].data)
+	dict, err := boiGetDictVar(context, name)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	delete(dict, string(args[1].data))
+	output := boiDictToVar(dict)
+	context.parentCtx.Set(name, output)
+	return output, nil
+}
+
+// BoiFuncDictKeys returns the keys of a dict as a sorted list
+func BoiFuncDictKeys(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("dict-keys requires 1 parameter")
+	}
+	dict, err := boiVarToDict(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	keys := []BoiVar{}
+	for _, key := range boiSortedKeys(dict) {
+		keys = append(keys, BoiVar{[]byte(key)})
+	}
+	return boiListToVar(keys), nil
+}
+
+func BoiFuncDictLen(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("dict-len requires 1 parameter")
+	}
+	dict, err := boiVarToDict(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	return boiIndexToVar(len(dict)), nil
+}
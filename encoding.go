@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+// These functions turn values into printable text and back, which is
+// useful for values like integers that say would print as raw bytes.
+
+func BoiFuncHexEncode(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("hex-encode requires 1 parameter")
+	}
+	return BoiVar{[]byte(hex.EncodeToString(args[0].data))}, nil
+}
+
+func BoiFuncHexDecode(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("hex-decode requires 1 parameter")
+	}
+	data, err := hex.DecodeString(string(args[0].data))
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("hex-decode: %v", err)
+	}
+	return BoiVar{data}, nil
+}
+
+// boiBase64Encode and boiBase64Decode make the base64 functions for an
+// encoding (standard, or the URL-safe one)
+func boiBase64Encode(name string, encoding *base64.Encoding) BoiGoFunc {
+	return func(context *BoiContext, args []BoiVar) (BoiVar, error) {
+		if len(args) != 1 {
+			return BoiVar{}, fmt.Errorf("%s requires 1 parameter", name)
+		}
+		return BoiVar{[]byte(encoding.EncodeToString(args[0].data))}, nil
+	}
+}
+
+func boiBase64Decode(name string, encoding *base64.Encoding) BoiGoFunc {
+	return func(context *BoiContext, args []BoiVar) (BoiVar, error) {
+		if len(args) != 1 {
+			return BoiVar{}, fmt.Errorf("%s requires 1 parameter", name)
+		}
+		data, err := encoding.DecodeString(string(args[0].data))
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("%s: %v", name, err)
+		}
+		return BoiVar{data}, nil
+	}
+}
+
+// BoiFuncURLEscape makes a value safe to put in a URL's query string
+func BoiFuncURLEscape(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("url-escape requires 1 parameter")
+	}
+	return BoiVar{[]byte(url.QueryEscape(string(args[0].data)))}, nil
+}
+
+func BoiFuncURLUnescape(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("url-unescape requires 1 parameter")
+	}
+	text, err := url.QueryUnescape(string(args[0].data))
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("url-unescape: %v", err)
+	}
+	return BoiVar{[]byte(text)}, nil
+}
+
+// BoiFuncUTF8Valid returns true if a value is valid UTF-8 text
+func BoiFuncUTF8Valid(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("utf8-valid requires 1 parameter")
+	}
+	return boiBool(utf8.Valid(args[0].data)), nil
+}
+
+// BoiFuncRuneCount counts characters rather than bytes (see len). Bytes
+// that aren't valid UTF-8 count as one character each.
+func BoiFuncRuneCount(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("rune-count requires 1 parameter")
+	}
+	return boiIndexToVar(utf8.RuneCount(args[0].data)), nil
+}
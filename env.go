@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// These functions use the process's environment variables, unless the
+// interpreter was given a different environment with SetEnvironment.
+
+// BoiFuncGetEnv returns an environment variable. Like icanhas, it sets
+// "exists" so a missing variable can be told apart from an empty one.
+type BoiFuncGetEnv struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncGetEnv) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("get-env requires 1 parameter")
+	}
+	name := string(args[0].data)
+	var value string
+	var exists bool
+	if env := f.interpreter.env; env != nil {
+		value, exists = env[name]
+	} else {
+		value, exists = os.LookupEnv(name)
+	}
+	context.variables["exists"] = boiBool(exists)
+	return BoiVar{[]byte(value)}, nil
+}
+
+type BoiFuncSetEnv struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncSetEnv) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("set-env requires 2 parameters")
+	}
+	name, value := string(args[0].data), string(args[1].data)
+	if env := f.interpreter.env; env != nil {
+		env[name] = value
+		return args[1], nil
+	}
+	return args[1], os.Setenv(name, value)
+}
+
+// BoiFuncListEnv returns every environment variable in a dict
+type BoiFuncListEnv struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncListEnv) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("list-env doesn't take parameters")
+	}
+	dict := map[string]BoiVar{}
+	if env := f.interpreter.env; env != nil {
+		for name, value := range env {
+			dict[name] = BoiVar{[]byte(value)}
+		}
+		return boiDictToVar(dict), nil
+	}
+	for _, entry := range os.Environ() {
+		if eq := strings.Index(entry, "="); eq >= 0 {
+			dict[entry[:eq]] = BoiVar{[]byte(entry[eq+1:])}
+		}
+	}
+	return boiDictToVar(dict), nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// BoiFuncEval runs its first parameter as Boi code. The code runs in the
+// caller's scope, so it can see and change the caller's variables, unless
+// the second parameter is "child", which runs it in a new scope instead.
+// It returns whatever the last call in the code returned.
+type BoiFuncEval struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncEval) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return BoiVar{}, errors.New("eval requires 1 or 2 parameters")
+	}
+
+	boi := f.interpreter
+	ctx := context.parentCtx
+	if len(args) > 1 {
+		switch mode := string(args[1].data); mode {
+		case "child":
+			ctx = &BoiContext{
+				map[string]BoiFunc{},
+				map[string]BoiVar{},
+				ctx, nil, nil, nil,
+			}
+		case "caller":
+		default:
+			return BoiVar{}, fmt.Errorf(
+				"eval runs code in caller or child, not '%s'", mode,
+			)
+		}
+	}
+
+	// Code can eval itself, so this counts toward the call depth just
+	// like calling a function does
+	if err := boi.checkCallDepth("eval"); err != nil {
+		return BoiVar{}, err
+	}
+	boi.evals++
+	defer func() { boi.evals-- }()
+
+	// The interpreter reads code from input, so the code being evaluated
+	// takes its place until it's done. Forgetting the last call's context
+	// means only calls made by the code count for the returned value.
+	input, pos, callerCtx := boi.input, boi.pos, boi.context
+	defer func() {
+		boi.input, boi.pos, boi.context = input, pos, callerCtx
+	}()
+	boi.input, boi.pos, boi.context = args[0].data, 0, ctx
+	ctx.returnCtx = nil
+
+	// This is Run, but errors say where the failing statement starts
+	for !boi.whitespace() {
+		start := boi.pos
+		if err := boi.doStatement(); err != nil {
+			line, column := boiPosition(boi.input, start)
+			return BoiVar{}, fmt.Errorf(
+				"eval line %d, column %d: %v", line, column, err,
+			)
+		}
+	}
+	if ctx.returnCtx == nil {
+		return BoiVar{[]byte{}}, nil
+	}
+	// A child scope is about to go away, so anything it's holding on to
+	// for the returned value has to be held here instead
+	output := ctx.returnCtx.variables["exit"]
+	context.adopt(output)
+	return output, nil
+}
+
+// boiPosition turns a position in some code into a line and column,
+// both counting from 1
+func boiPosition(code []byte, pos IntyBoi) (int, int) {
+	if pos > IntyBoi(len(code)) {
+		pos = IntyBoi(len(code))
+	}
+	before := code[:pos]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
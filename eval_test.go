@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalRecursionOverflows(t *testing.T) {
+	_, err := runBoi(t, `
+boi: c "boi! eval boi:c boi" boi
+boi! eval boi:c boi
+`, func(boi *BoiInterpreter) { boi.SetMaxCallDepth(50) })
+	if err == nil {
+		t.Fatal("expected a stack overflow")
+	}
+	if !strings.Contains(err.Error(), "stack overflow in eval (max call depth is 50)") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEvalWithoutCallsReturnsNothing(t *testing.T) {
+	boi, err := runBoi(t, `
+boi! upper zzz boi
+boi: x [eval " "] boi
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := boi.context.Get("x"); len(x.data) != 0 {
+		t.Errorf("x is %q, expected nothing", x.data)
+	}
+}
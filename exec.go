@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// BoiFuncExec runs a program with arguments and returns what it printed
+// to standard output. It also sets "stdout", "stderr" and "status" (the
+// exit status, as an integer), so a failing program isn't an error; not
+// being able to run it at all is. The exec-with-stdin version takes the
+// program's input as its first parameter.
+type BoiFuncExec struct {
+	interpreter *BoiInterpreter
+	withStdin   bool
+}
+
+func (f BoiFuncExec) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	name := "exec"
+	var stdin []byte
+	if f.withStdin {
+		name = "exec-with-stdin"
+		if len(args) < 1 {
+			return BoiVar{}, errors.New("exec-with-stdin requires input")
+		}
+		stdin, args = args[0].data, args[1:]
+	}
+	if !f.interpreter.Can(BoiCapExec) {
+		return BoiVar{}, fmt.Errorf(
+			"%s: running programs isn't allowed (run boi with -exec)", name,
+		)
+	}
+	if len(args) < 1 {
+		return BoiVar{}, fmt.Errorf("%s requires a program to run", name)
+	}
+
+	cmdArgs := []string{}
+	for _, arg := range args[1:] {
+		cmdArgs = append(cmdArgs, string(arg.data))
+	}
+	ctx, cancel := f.interpreter.deadlineContext()
+	defer cancel()
+	cmd := exec.CommandContext(ctx, string(args[0].data), cmdArgs...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if env := f.interpreter.env; env != nil {
+		// Programs see the same environment the script does. This is
+		// never nil, as a nil Env would mean the real environment.
+		cmd.Env = []string{}
+		for key, value := range env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		// The program was killed because the script ran out of time
+		return BoiVar{}, fmt.Errorf(
+			"%s %s: %v", name, args[0].data, f.interpreter.timedOut(),
+		)
+	}
+	status := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		status = exitErr.ExitCode()
+	} else if err != nil {
+		return BoiVar{}, fmt.Errorf("%s: %v", name, err)
+	}
+
+	context.variables["stdout"] = BoiVar{stdout.Bytes()}
+	context.variables["stderr"] = BoiVar{stderr.Bytes()}
+	context.variables["status"] = boiIndexToVar(status)
+	return BoiVar{stdout.Bytes()}, nil
+}
+
+// deadlineContext returns a Go context that's cancelled when the current
+// Run is out of time. The interpreter's clock might not be the real one,
+// so this goes by how much time is left rather than the deadline itself.
+func (boi *BoiInterpreter) deadlineContext() (
+	context.Context, context.CancelFunc,
+) {
+	if boi.deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(
+		context.Background(), boi.deadline.Sub(boi.clock.Now()),
+	)
+}
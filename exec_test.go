@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecUsesScriptEnvironment(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh to run")
+	}
+	os.Setenv("SCRIPT_REAL_ONLY", "real")
+	defer os.Unsetenv("SCRIPT_REAL_ONLY")
+
+	boi, err := runBoi(t, `
+boi! set-env SCRIPT_MADE_UP made boi
+boi: result [exec sh -c "printf %s $SCRIPT_MADE_UP$SCRIPT_REAL_ONLY"] boi
+`, func(boi *BoiInterpreter) {
+		boi.Grant(BoiCapExec)
+		boi.SetEnvironment(map[string]string{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := boi.context.Get("result"); string(result.data) != "made" {
+		t.Errorf("program saw %q, expected only the script's environment", result.data)
+	}
+}
+
+func TestExecPastTimeoutWithFrozenClock(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep to run")
+	}
+	_, err := runBoi(t, "boi! exec sleep 5 boi\n", func(boi *BoiInterpreter) {
+		boi.Grant(BoiCapExec)
+		boi.SetClock(frozenClock{})
+		boi.SetTimeout(100 * time.Millisecond)
+	})
+	if err == nil || !strings.Contains(err.Error(), "exec sleep: timed out") {
+		t.Errorf("expected the program to time out, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BoiFileSystem is what the file functions use to get at files. *os.Root
+// is one, which keeps every path inside a directory, symlinks included.
+type BoiFileSystem interface {
+	Open(name string) (*os.File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (*os.File, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(name string, perm os.FileMode) error
+	Remove(name string) error
+	Stat(name string) (os.FileInfo, error)
+}
+
+// boiHostFileSystem is the whole filesystem, for when there's no root
+type boiHostFileSystem struct{}
+
+func (boiHostFileSystem) Open(name string) (*os.File, error) {
+	return os.Open(name)
+}
+
+func (boiHostFileSystem) OpenFile(
+	name string, flag int, perm os.FileMode,
+) (*os.File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (boiHostFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (boiHostFileSystem) WriteFile(
+	name string, data []byte, perm os.FileMode,
+) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (boiHostFileSystem) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (boiHostFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (boiHostFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// BoiFuncFile runs one of the file functions below. They all take a path
+// as their first parameter, and only work if the interpreter was granted
+// BoiCapFilesystem. If there's a filesystem root, the path is taken
+// relative to it and can't leave it, by ".." or by a symlink.
+type BoiFuncFile struct {
+	interpreter *BoiInterpreter
+	name        string
+	params      int
+	run         func(fsys BoiFileSystem, path string, args []BoiVar) (BoiVar, error)
+}
+
+func (boi *BoiInterpreter) registerFileFunction(
+	fname string,
+	params int,
+	run func(fsys BoiFileSystem, path string, args []BoiVar) (BoiVar, error),
+) {
+	boi.RegisterGoFunctionStruct(fname, BoiFuncFile{boi, fname, params, run})
+}
+
+func (f BoiFuncFile) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if !f.interpreter.Can(BoiCapFilesystem) {
+		return BoiVar{}, fmt.Errorf(
+			"%s: file access isn't allowed (run boi with -fs)", f.name,
+		)
+	}
+	if len(args) != f.params {
+		return BoiVar{}, fmt.Errorf(
+			"%s requires %d parameters", f.name, f.params,
+		)
+	}
+	var fsys BoiFileSystem = boiHostFileSystem{}
+	path := string(args[0].data)
+	if f.interpreter.fsRoot != "" {
+		root, err := os.OpenRoot(f.interpreter.fsRoot)
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("%s: %v", f.name, err)
+		}
+		defer root.Close()
+		fsys = root
+		path = boiRootPath(path)
+	}
+	output, err := f.run(fsys, path, args[1:])
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("%s: %v", f.name, err)
+	}
+	return output, nil
+}
+
+// boiRootPath turns a path from a script into one relative to the root.
+// Cleaning the path as if it were absolute gets rid of any ".." that
+// would climb out of it, and the root itself refuses symlinks that do.
+func boiRootPath(path string) string {
+	sep := string(filepath.Separator)
+	path = strings.TrimPrefix(filepath.Clean(sep+path), sep)
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+func boiReadFile(
+	fsys BoiFileSystem, path string, args []BoiVar,
+) (BoiVar, error) {
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	return BoiVar{data}, nil
+}
+
+// boiWriteFile replaces a file's contents, creating it if needed
+func boiWriteFile(
+	fsys BoiFileSystem, path string, args []BoiVar,
+) (BoiVar, error) {
+	return args[0], fsys.WriteFile(path, args[0].data, 0644)
+}
+
+// boiAppendFile adds to the end of a file, creating it if needed
+func boiAppendFile(
+	fsys BoiFileSystem, path string, args []BoiVar,
+) (BoiVar, error) {
+	file, err := fsys.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	if _, err := file.Write(args[0].data); err != nil {
+		file.Close()
+		return BoiVar{}, err
+	}
+	return args[0], file.Close()
+}
+
+func boiFileExists(
+	fsys BoiFileSystem, path string, args []BoiVar,
+) (BoiVar, error) {
+	_, err := fsys.Stat(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return BoiVar{}, err
+	}
+	return boiBool(err == nil), nil
+}
+
+// boiListDir returns a sorted list of the names in a directory
+func boiListDir(
+	fsys BoiFileSystem, path string, args []BoiVar,
+) (BoiVar, error) {
+	dir, err := fsys.Open(path)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	entries, err := dir.ReadDir(-1)
+	dir.Close()
+	if err != nil {
+		return BoiVar{}, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	output := []BoiVar{}
+	for _, name := range names {
+		output = append(output, BoiVar{[]byte(name)})
+	}
+	return boiListToVar(output), nil
+}
+
+// boiMkdir makes a directory, along with any parents it needs
+func boiMkdir(
+	fsys BoiFileSystem, path string, args []BoiVar,
+) (BoiVar, error) {
+	return BoiVar{}, fsys.MkdirAll(path, 0755)
+}
+
+// boiRemove deletes a file or an empty directory
+func boiRemove(
+	fsys BoiFileSystem, path string, args []BoiVar,
+) (BoiVar, error) {
+	return BoiVar{}, fsys.Remove(path)
+}
+
+// boiStat returns a dict describing a file
+func boiStat(
+	fsys BoiFileSystem, path string, args []BoiVar,
+) (BoiVar, error) {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	return boiDictToVar(map[string]BoiVar{
+		"name":     BoiVar{[]byte(info.Name())},
+		"size":     boiIndexToVar(int(info.Size())),
+		"dir":      boiBool(info.IsDir()),
+		"mode":     BoiVar{[]byte(info.Mode().String())},
+		"modified": BoiVar{[]byte(info.ModTime().Format(time.RFC3339))},
+	}), nil
+}
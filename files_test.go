@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilesystemRootKeepsSymlinksInside(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(
+		filepath.Join(outside, "secret"), []byte("secret"), 0644,
+	); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "e")); err != nil {
+		t.Skip("can't make symlinks here")
+	}
+	if err := os.WriteFile(
+		filepath.Join(root, "inside"), []byte("inside"), 0644,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	setup := func(boi *BoiInterpreter) {
+		boi.Grant(BoiCapFilesystem)
+		boi.SetFilesystemRoot(root)
+	}
+	boi, err := runBoi(t, "boi: x [read-file ../../inside] boi\n", setup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := boi.context.Get("x"); string(x.data) != "inside" {
+		t.Errorf("read %q through .., expected the file in the root", x.data)
+	}
+	if _, err := runBoi(t, "boi: x [read-file e/secret] boi\n", setup); err == nil {
+		t.Error("read a file outside the root through a symlink")
+	}
+	if _, err := runBoi(t, "boi! write-file e/new escaped boi\n", setup); err == nil {
+		t.Error("wrote a file outside the root through a symlink")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new")); err == nil {
+		t.Error("file was created outside the root")
+	}
+}
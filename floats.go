@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Decimal values are big.Rat numbers, which keeps arithmetic exact (0.1 +
+// 0.2 is actually 0.3). Like lists, they start with a magic prefix so
+// they can be told apart from integers, followed by the number as a
+// fraction in text ("1/3", or just "5"). The decimal functions also take
+// integers (including an empty value, which is zero) and promote them.
+var boiDecimalMagic = []byte("\x00boi:decimal\x00")
+
+func boiIsDecimal(v BoiVar) bool {
+	return bytes.HasPrefix(v.data, boiDecimalMagic)
+}
+
+// boiFloatyDefaultPrecision is the number of digits after the decimal
+// point fdec uses when none is given
+const boiFloatyDefaultPrecision = 10
+
+// boiMaxFloatyPrecision is the most digits after the decimal point fdec
+// will write, so a script can't use up all the memory with one call
+const boiMaxFloatyPrecision = 10000
+
+func boiVarToRat(v BoiVar) (*big.Rat, error) {
+	switch true {
+	case boiIsDecimal(v):
+		text := string(v.data[len(boiDecimalMagic):])
+		if r, ok := new(big.Rat).SetString(text); ok {
+			return r, nil
+		}
+		return nil, errors.New("corrupted decimal, boi")
+	case boiIsList(v), boiIsDict(v):
+		return nil, errors.New("value is not a number")
+	}
+	return new(big.Rat).SetInt(new(big.Int).SetBytes(v.data)), nil
+}
+
+func boiRatToVar(r *big.Rat) BoiVar {
+	data := append([]byte{}, boiDecimalMagic...)
+	return BoiVar{append(data, r.RatString()...)}
+}
+
+// boiFormatRat writes a decimal with up to boiFloatyDefaultPrecision
+// digits after the decimal point, leaving off trailing zeros
+func boiFormatRat(r *big.Rat) string {
+	output := r.FloatString(boiFloatyDefaultPrecision)
+	if strings.Contains(output, ".") {
+		output = strings.TrimRight(output, "0")
+		output = strings.TrimSuffix(output, ".")
+	}
+	if output == "-0" {
+		output = "0"
+	}
+	return output
+}
+
+// BoiFuncFloat parses decimal strings like "3.14", "-2", "1e3" or "1/3"
+// and adds them together, the same way the int function does.
+func BoiFuncFloat(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	sum := new(big.Rat)
+	for _, arg := range args {
+		tmp, ok := new(big.Rat).SetString(string(arg.data))
+		if !ok {
+			return BoiVar{}, fmt.Errorf(
+				"float: can't parse '%s'", string(arg.data),
+			)
+		}
+		sum.Add(sum, tmp)
+	}
+	return boiRatToVar(sum), nil
+}
+
+// BoiFuncFdec formats a float as a decimal string. An optional second
+// parameter (an integer) sets the number of digits after the decimal
+// point; otherwise trailing zeros are trimmed.
+func BoiFuncFdec(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	value := new(big.Rat)
+	if len(args) > 0 {
+		var err error
+		if value, err = boiVarToRat(args[0]); err != nil {
+			return BoiVar{}, err
+		}
+	}
+
+	if len(args) > 1 {
+		prec, err := boiVarToIndex(args[1])
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("fdec: %v", err)
+		}
+		if prec > boiMaxFloatyPrecision {
+			return BoiVar{}, fmt.Errorf(
+				"fdec: can't write more than %d digits after the point",
+				boiMaxFloatyPrecision,
+			)
+		}
+		return BoiVar{[]byte(value.FloatString(prec))}, nil
+	}
+	return BoiVar{[]byte(boiFormatRat(value))}, nil
+}
+
+// BoiFuncFloaty converts an integer value (as produced by int, +, etc)
+// into a float value. The other decimal functions do this by themselves,
+// so it's only needed to make a value print as a decimal.
+func BoiFuncFloaty(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("floaty requires 1 parameter")
+	}
+	value, err := boiVarToRat(args[0])
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("floaty: %v", err)
+	}
+	return boiRatToVar(value), nil
+}
+
+// BoiFuncInty converts a float value into an integer value, dropping
+// anything after the decimal point.
+func BoiFuncInty(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("inty requires 1 parameter")
+	}
+	value, err := boiVarToRat(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	if value.Sign() < 0 {
+		return BoiVar{}, errors.New("inty: integers can't be negative, boi")
+	}
+	whole := new(big.Int).Quo(value.Num(), value.Denom())
+	return BoiVar{whole.Bytes()}, nil
+}
+
+// boiFloatyOp adapts a big.Rat operation into a Boi function that folds
+// it over all of its arguments, left to right
+func boiFloatyOp(
+	name string, op func(a, b *big.Rat) (*big.Rat, error),
+) BoiGoFunc {
+	return func(context *BoiContext, args []BoiVar) (BoiVar, error) {
+		if len(args) < 1 {
+			return BoiVar{}, fmt.Errorf(
+				"%s requires at least 1 parameter", name,
+			)
+		}
+		result, err := boiVarToRat(args[0])
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("%s: %v", name, err)
+		}
+		for _, arg := range args[1:] {
+			tmp, err := boiVarToRat(arg)
+			if err != nil {
+				return BoiVar{}, fmt.Errorf("%s: %v", name, err)
+			}
+			if result, err = op(result, tmp); err != nil {
+				return BoiVar{}, fmt.Errorf("%s: %v", name, err)
+			}
+		}
+		return boiRatToVar(result), nil
+	}
+}
+
+var (
+	BoiFuncFloatyAdd = boiFloatyOp("f+", func(a, b *big.Rat) (*big.Rat, error) {
+		return a.Add(a, b), nil
+	})
+	BoiFuncFloatySub = boiFloatyOp("f-", func(a, b *big.Rat) (*big.Rat, error) {
+		return a.Sub(a, b), nil
+	})
+	BoiFuncFloatyMul = boiFloatyOp("f*", func(a, b *big.Rat) (*big.Rat, error) {
+		return a.Mul(a, b), nil
+	})
+	BoiFuncFloatyDiv = boiFloatyOp("f/", func(a, b *big.Rat) (*big.Rat, error) {
+		if b.Sign() == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return a.Quo(a, b), nil
+	})
+)
+
+func BoiFuncFloatyLess(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 2 {
+		return BoiVar{}, errors.New("f< requires at least 2 parameters")
+	}
+	for i := 0; i < len(args)-1; i++ {
+		a, err := boiVarToRat(args[i])
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("f<: %v", err)
+		}
+		b, err := boiVarToRat(args[i+1])
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("f<: %v", err)
+		}
+		if a.Cmp(b) >= 0 {
+			return BoiVar{[]byte("false")}, nil
+		}
+	}
+	return BoiVar{[]byte("true")}, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// A function value is simply the function's name; anonymous functions get
+// a generated one (see BoiOpFuncDef and boiAnonymousFunctions). The functions
+// in this file take a function value as their first parameter and call it
+// for each element of a list.
+
+// boiCallFunction calls a function value from inside a Go function and
+// returns whatever the called function set as its exit value
+func boiCallFunction(
+	context *BoiContext, f BoiVar, args []BoiVar,
+) (BoiVar, error) {
+	if err := context.Call(string(f.data), args); err != nil {
+		return BoiVar{}, err
+	}
+	if context.returnCtx == nil {
+		return BoiVar{}, nil
+	}
+	return context.returnCtx.variables["exit"], nil
+}
+
+// BoiFuncMap calls a function for each element of a list, and returns a
+// list of the results
+func BoiFuncMap(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("map requires 2 parameters")
+	}
+	items, err := boiVarToList(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	output := []BoiVar{}
+	for _, item := range items {
+		result, err := boiCallFunction(context, args[0], []BoiVar{item})
+		if err != nil {
+			return BoiVar{}, err
+		}
+		output = append(output, result)
+	}
+	return boiListToVar(output), nil
+}
+
+// BoiFuncFilter returns a list of the elements for which a function
+// returns something true
+func BoiFuncFilter(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("filter requires 2 parameters")
+	}
+	items, err := boiVarToList(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	output := []BoiVar{}
+	for _, item := range items {
+		result, err := boiCallFunction(context, args[0], []BoiVar{item})
+		if err != nil {
+			return BoiVar{}, err
+		}
+		if result.IsTrue() {
+			output = append(output, item)
+		}
+	}
+	return boiListToVar(output), nil
+}
+
+// BoiFuncReduce calls a function with an accumulated value and each
+// element of a list in turn. Each result becomes the next accumulated
+// value, and the last one is returned.
+func BoiFuncReduce(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 3 {
+		return BoiVar{}, errors.New("reduce requires 3 parameters")
+	}
+	items, err := boiVarToList(args[2])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	acc := args[1]
+	for _, item := range items {
+		acc, err = boiCallFunction(context, args[0], []BoiVar{acc, item})
+		if err != nil {
+			return BoiVar{}, err
+		}
+	}
+	return acc, nil
+}
+
+// BoiFuncApply calls a function with the elements of a list as its
+// arguments
+func BoiFuncApply(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("apply requires 2 parameters")
+	}
+	items, err := boiVarToList(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	return boiCallFunction(context, args[0], items)
+}
+
+// BoiFuncSortBy sorts a list using a function that returns true when its
+// first argument belongs before its second, so < and before work as-is.
+// Elements that are equal keep their order.
+func BoiFuncSortBy(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("sort-by requires 2 parameters")
+	}
+	items, err := boiVarToList(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	var callErr error
+	sort.SliceStable(items, func(i, j int) bool {
+		if callErr != nil {
+			return false
+		}
+		result, err := boiCallFunction(
+			context, args[0], []BoiVar{items[i], items[j]},
+		)
+		if err != nil {
+			callErr = fmt.Errorf("sort-by: %v", err)
+			return false
+		}
+		return result.IsTrue()
+	})
+	if callErr != nil {
+		return BoiVar{}, callErr
+	}
+	return boiListToVar(items), nil
+}
@@ -1,10 +1,10 @@
 package main
 
 import (
-	"crypto/rand"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // BoiGoFunc defines a function that can be invoked
@@ -53,39 +53,321 @@ func (structure BoiGoFuncAsFuncStruct) Run(
 	return structure.function(ctx, args)
 }
 
+// BoiParam is a parameter declared in an oh statement. Parameters are
+// written as "name", "name=default" or "name..." (the variadic tail,
+// which collects the remaining arguments into a list).
+type BoiParam struct {
+	Name       string
+	Default    BoiVar
+	HasDefault bool
+	Variadic   bool
+}
+
+// ParseBoiParams reads parameter declarations from the values that follow
+// a function's name in an oh statement
+func ParseBoiParams(values []BoiVar) ([]BoiParam, error) {
+	params := []BoiParam{}
+	for i, value := range values {
+		decl := string(value.data)
+		param := BoiParam{Name: decl}
+		if eq := strings.Index(decl, "="); eq >= 0 {
+			param.Name = decl[:eq]
+			param.Default = BoiVar{[]byte(decl[eq+1:])}
+			param.HasDefault = true
+		} else if strings.HasSuffix(decl, "...") {
+			param.Name = strings.TrimSuffix(decl, "...")
+			param.Variadic = true
+			if i != len(values)-1 {
+				return nil, fmt.Errorf(
+					"parameter %s... must be the last parameter", param.Name,
+				)
+			}
+		} else if i > 0 && params[i-1].HasDefault {
+			return nil, fmt.Errorf(
+				"parameter %s must have a default, since %s has one",
+				param.Name, params[i-1].Name,
+			)
+		}
+		if param.Name == "" {
+			return nil, errors.New("parameter names can't be empty")
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
 // BoiStatementsFunction implements BoiGoFuncStruct, and runs aggregate
 // BoiStatement objects in order.
 type BoiStatementsFunction struct {
+	name        string
+	params      []BoiParam
 	statements  []*BoiStatement
 	interpreter *BoiInterpreter
+
+	// closure is the context the function was defined in, if it uses
+	// lexical scoping. When it's nil, the function uses dynamic scoping
+	// and sees its caller's variables instead.
+	closure *BoiContext
 }
 
 func NewBoiStatementsFunction(
+	name string,
+	params []BoiParam,
 	statements []*BoiStatement,
 	interpreter *BoiInterpreter,
+	closure *BoiContext,
 ) *BoiStatementsFunction {
 	return &BoiStatementsFunction{
-		statements, interpreter,
+		name, params, statements, interpreter, closure,
 	}
 }
 
+// checkArity makes sure a function that declared parameters is called
+// with a number of arguments that fits them. Functions that didn't
+// declare any parameters accept anything.
+func (f *BoiStatementsFunction) checkArity(count int) error {
+	if len(f.params) == 0 {
+		return nil
+	}
+	min, max := 0, len(f.params)
+	for _, param := range f.params {
+		if param.Variadic {
+			max = -1
+		} else if !param.HasDefault {
+			min++
+		}
+	}
+	if count >= min && (max < 0 || count <= max) {
+		return nil
+	}
+
+	expected := fmt.Sprintf("%d to %d", min, max)
+	switch true {
+	case max < 0:
+		expected = fmt.Sprintf("at least %d", min)
+	case min == max:
+		expected = strconv.Itoa(min)
+	}
+	return fmt.Errorf(
+		"function %s expects %s arguments, got %d", f.name, expected, count,
+	)
+}
+
 func (f *BoiStatementsFunction) Run(
 	ctx *BoiContext, args []BoiVar,
 ) (BoiVar, error) {
-	for i, val := range args {
-		ctx.Set(string("arg."+strconv.Itoa(i)), val)
+	if err := f.checkArity(len(args)); err != nil {
+		return BoiVar{}, err
 	}
-	for _, stmt := range f.statements {
-		err := f.interpreter.ExecStmt(stmt)
+
+	boi := f.interpreter
+	if err := boi.checkCallDepth("function " + f.name); err != nil {
+		return BoiVar{}, err
+	}
+	boi.calls = append(boi.calls, f.name)
+	defer func() { boi.calls = boi.calls[:len(boi.calls)-1] }()
+
+	// With lexical scoping, the function body runs in a context whose
+	// parent is where the function was defined instead of the caller
+	if f.closure != nil {
+		callerCtx := ctx
+		ctx = &BoiContext{
+			map[string]BoiFunc{},
+			map[string]BoiVar{},
+			f.closure, nil, nil, nil,
+		}
+		f.interpreter.context = ctx
+		defer func() {
+			f.interpreter.context = callerCtx
+			callerCtx.adopt(ctx.variables["exit"])
+		}()
+	}
+
+	for {
+		// A function that does nothing but call itself never gets to
+		// ExecStmt, so it has to watch the clock itself
+		if err := boi.checkDeadline(); err != nil {
+			return BoiVar{}, err
+		}
+		f.bind(ctx, args)
+		tail, err := f.runStatements(f.statements)
 		if err != nil {
 			return BoiVar{}, err
 		}
+		if tail == nil {
+			return ctx.variables["exit"], nil
+		}
+
+		// The function ended by calling itself, so run it again with the
+		// new arguments instead of nesting a call. Each run still gets its
+		// own context, so closures made by earlier runs keep their
+		// variables, but it replaces the last one instead of going inside
+		// it, so the chain of contexts doesn't grow.
+		if err := f.checkArity(len(tail)); err != nil {
+			return BoiVar{}, err
+		}
+		done := ctx
+		ctx = f.tailContext(done)
+		boi.context = ctx
+		ctx.adopt(tail...)
+		args = tail
+	}
+}
+
+// tailContext makes the context for running the function again after
+// the run in done ends with a tail call
+func (f *BoiStatementsFunction) tailContext(done *BoiContext) *BoiContext {
+	ctx := &BoiContext{
+		map[string]BoiFunc{},
+		map[string]BoiVar{},
+		done.parentCtx, nil, nil, nil,
+	}
+	if f.closure != nil {
+		return ctx
+	}
+
+	// With dynamic scoping, a nested call would see the variables and
+	// functions of the run that called it (except its arguments), so
+	// they're copied over. Changes to them can't be seen afterwards,
+	// since the run that had them is over either way.
+	adopt := done.ownsAnonymous()
+	for name, v := range done.variables {
+		if !strings.HasPrefix(name, "arg.") {
+			ctx.variables[name] = v
+			if adopt {
+				ctx.adopt(v)
+			}
+		}
+	}
+	for name, fn := range done.functions {
+		if !strings.HasPrefix(name, boiAnonymousPrefix) {
+			ctx.functions[name] = fn
+		}
+	}
+	return ctx
+}
+
+// bind sets up the local variables for a call
+func (f *BoiStatementsFunction) bind(ctx *BoiContext, args []BoiVar) {
+	// Arguments are always local to the call; using ctx.Set here would
+	// overwrite the caller's arguments instead. The same goes for exit,
+	// which is why it starts out empty.
+	ctx.function = f
+	ctx.variables["exit"] = BoiVar{[]byte{}}
+	for i, val := range args {
+		ctx.variables["arg."+strconv.Itoa(i)] = val
+	}
+	ctx.variables["arg.count"] = boiIndexToVar(len(args))
+	ctx.variables["arg.all"] = boiListToVar(args)
+	for i, param := range f.params {
+		switch true {
+		case param.Variadic:
+			rest := []BoiVar{}
+			if i < len(args) {
+				rest = args[i:]
+			}
+			ctx.variables[param.Name] = boiListToVar(rest)
+		case i < len(args):
+			ctx.variables[param.Name] = args[i]
+		default:
+			ctx.variables[param.Name] = param.Default
+		}
+	}
+}
+
+// runStatements runs statements from the function's body. If the last one
+// is a tail call (see tailCall), it isn't made; its arguments are returned
+// instead, so Run can loop rather than grow the stack.
+func (f *BoiStatementsFunction) runStatements(
+	stmts []*BoiStatement,
+) ([]BoiVar, error) {
+	last := len(stmts) - 1
+	for last >= 0 && stmts[last] == nil {
+		last--
+	}
+	for i, stmt := range stmts[:last+1] {
+		if stmt == nil {
+			continue
+		}
+		if i == last {
+			tail, handled, err := f.tailCall(stmt)
+			if handled || err != nil {
+				return tail, err
+			}
+		}
+		if err := f.interpreter.ExecStmt(stmt); err != nil {
+			return nil, err
+		}
 	}
-	exitValue, exists := ctx.variables["exit"]
-	if !exists {
-		exitValue = BoiVar{[]byte{}}
+	return nil, nil
+}
+
+// tailCall handles a statement in tail position. That's either
+//
+//	boi: exit [name args...] boi
+//
+// where name is this function, or a boi? whose last statement is in tail
+// position itself. handled is false if the statement should just be run.
+func (f *BoiStatementsFunction) tailCall(
+	stmt *BoiStatement,
+) (tail []BoiVar, handled bool, err error) {
+	boi := f.interpreter
+	switch stmt.Operation {
+	case BoiOpCall:
+		if !f.isSelfCall(stmt) {
+			return nil, false, nil
+		}
+		tail, err = boi.evalTokens(stmt.Tokens[2].Children[1:])
+		return tail, true, err
+	case BoiOpIf:
+		if len(stmt.Tokens) < 1 {
+			return nil, false, nil
+		}
+		isTrue, err := boi.evalCondition(stmt.Tokens)
+		if err != nil || !isTrue {
+			return nil, true, err
+		}
+		// This is returnContext, but functions passed to the tail call
+		// are kept too
+		block := boi.subContext()
+		tail, err = f.runStatements(stmt.Children)
+		boi.context = block.parentCtx
+		boi.context.returnCtx = block
+		if tail != nil && f.closure == nil {
+			// With dynamic scoping, a nested call would see the block's
+			// variables, so they go where tailContext will copy them from
+			adopt := block.ownsAnonymous()
+			for name, v := range block.variables {
+				boi.context.variables[name] = v
+				if adopt {
+					boi.context.adopt(v)
+				}
+			}
+		}
+		boi.context.adopt(append(tail, block.variables["exit"])...)
+		return tail, true, err
 	}
-	return exitValue, nil
+	return nil, false, nil
+}
+
+// isSelfCall reports whether a statement sets exit to the result of
+// calling this same function
+func (f *BoiStatementsFunction) isSelfCall(stmt *BoiStatement) bool {
+	if len(stmt.Tokens) != 3 {
+		return false
+	}
+	cmd, name, call := stmt.Tokens[0], stmt.Tokens[1], stmt.Tokens[2]
+	if cmd.BoiType != BoiTokenValue || string(cmd.BoiValue) != "set" ||
+		name.BoiType != BoiTokenValue || string(name.BoiValue) != "exit" ||
+		call.BoiType != BoiTokenCall || len(call.Children) < 1 ||
+		call.Children[0].BoiType != BoiTokenValue {
+		return false
+	}
+	found, exists := f.interpreter.context.Lookup(
+		string(call.Children[0].BoiValue),
+	)
+	adapter, ok := found.(BoiGoFunctionAdapter)
+	return exists && ok && adapter.function == BoiGoFuncStruct(f)
 }
 
 /*
@@ -96,7 +378,7 @@ func BoiFuncName(context *BoiContext, args []BoiVar) ([]byte, error) {
 
 func BoiFuncSay(context *BoiContext, args []BoiVar) (BoiVar, error) {
 	for _, bvar := range args {
-		fmt.Print(string(bvar.data))
+		fmt.Print(string(boiDisplay(bvar)))
 	}
 	fmt.Println()
 	return BoiVar{}, nil
@@ -131,7 +413,13 @@ func BoiFuncGet(context *BoiContext, args []BoiVar) (BoiVar, error) {
 // parameter. It instead initializes the variable to a completely random
 // value to **ensure** the application programmer can't make assumptions
 // about the value. Adding a value parameter anyway is undefined behaviour.
-func BoiFuncDeclare(context *BoiContext, args []BoiVar) (BoiVar, error) {
+type BoiFuncDeclare struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncDeclare) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
 	if len(args) < 1 {
 		return BoiVar{}, errors.New("one requires 1 parameters")
 	}
@@ -139,7 +427,7 @@ func BoiFuncDeclare(context *BoiContext, args []BoiVar) (BoiVar, error) {
 	//context.parentCtx.variables[key] = args[1]
 
 	value := make([]byte, 4)
-	_, err := rand.Read(value)
+	_, err := f.interpreter.random.Read(value)
 	if err != nil {
 		return BoiVar{}, err
 	}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runBoi runs code in a new interpreter and returns it, so tests can look
+// at the variables the code left behind
+func runBoi(t *testing.T, code string, setup ...func(*BoiInterpreter)) (
+	*BoiInterpreter, error,
+) {
+	t.Helper()
+	boi := NewBoiInterpreter([]byte(code))
+	for _, f := range setup {
+		f(boi)
+	}
+	return boi, boi.Run()
+}
+
+// boiIntVar reads an integer variable left by runBoi
+func boiIntVar(t *testing.T, boi *BoiInterpreter, name string) int64 {
+	t.Helper()
+	v, exists := boi.context.Get(name)
+	if !exists {
+		t.Fatalf("variable %s doesn't exist", name)
+	}
+	return new(big.Int).SetBytes(v.data).Int64()
+}
+
+const boiFactorial = `
+oh fact n boi
+    boi: exit [int 1] boi
+    boi? < [int 1] boi:n boi
+        boi: exit [* boi:n [fact [- boi:n [int 1]]]] boi
+    BOI
+BOI
+`
+
+const boiFibonacci = `
+oh fib n boi
+    boi: exit boi:n boi
+    boi? < [int 1] boi:n boi
+        boi: exit [+ [fib [- boi:n [int 1]]] [fib [- boi:n [int 2]]]] boi
+    BOI
+BOI
+`
+
+func TestRecursiveFactorial(t *testing.T) {
+	cases := map[string]int64{
+		"0": 1, "1": 1, "5": 120, "10": 3628800, "20": 2432902008176640000,
+	}
+	for n, expected := range cases {
+		boi, err := runBoi(t, boiFactorial+"boi: result [fact [int "+n+"]] boi\n")
+		if err != nil {
+			t.Fatalf("fact %s: %v", n, err)
+		}
+		if got := boiIntVar(t, boi, "result"); got != expected {
+			t.Errorf("fact %s = %d, expected %d", n, got, expected)
+		}
+	}
+}
+
+func TestRecursiveFibonacci(t *testing.T) {
+	cases := map[string]int64{"0": 0, "1": 1, "2": 1, "10": 55, "15": 610}
+	for n, expected := range cases {
+		boi, err := runBoi(t, boiFibonacci+"boi: result [fib [int "+n+"]] boi\n")
+		if err != nil {
+			t.Fatalf("fib %s: %v", n, err)
+		}
+		if got := boiIntVar(t, boi, "result"); got != expected {
+			t.Errorf("fib %s = %d, expected %d", n, got, expected)
+		}
+	}
+}
+
+func TestStackOverflowNamesFunction(t *testing.T) {
+	// Not a tail call, so every call really is nested
+	const deep = `
+oh deep n boi
+    boi: exit [+ [deep [+ boi:n [int 1]]] [int 1]] boi
+BOI
+boi! deep [int 0] boi
+`
+	_, err := runBoi(t, deep)
+	if err == nil {
+		t.Fatal("expected a stack overflow with the default depth")
+	}
+	if !strings.Contains(err.Error(), "stack overflow in function deep") ||
+		!strings.Contains(err.Error(), "max call depth is 1000") {
+		t.Errorf("unexpected error with the default depth: %v", err)
+	}
+
+	_, err = runBoi(t, deep, func(boi *BoiInterpreter) {
+		boi.SetMaxCallDepth(50)
+	})
+	if err == nil {
+		t.Fatal("expected a stack overflow with a depth of 50")
+	}
+	if !strings.Contains(err.Error(), "stack overflow in function deep") ||
+		!strings.Contains(err.Error(), "max call depth is 50") {
+		t.Errorf("unexpected error with a depth of 50: %v", err)
+	}
+}
+
+func TestMaxCallDepthAllowsDeepEnoughRecursion(t *testing.T) {
+	boi, err := runBoi(t, boiFactorial+"boi: result [fact [int 20]] boi\n",
+		func(boi *BoiInterpreter) { boi.SetMaxCallDepth(20) },
+	)
+	if err != nil {
+		t.Fatalf("fact 20 with a depth of 20: %v", err)
+	}
+	if got := boiIntVar(t, boi, "result"); got != 2432902008176640000 {
+		t.Errorf("fact 20 = %d", got)
+	}
+
+	_, err = runBoi(t, boiFactorial+"boi! fact [int 20] boi\n",
+		func(boi *BoiInterpreter) { boi.SetMaxCallDepth(19) },
+	)
+	if err == nil || !strings.Contains(err.Error(), "function fact") {
+		t.Errorf("fact 20 with a depth of 19: expected a stack overflow, got %v", err)
+	}
+}
+
+// boiSameWithoutTailCalls runs code that uses tail calls, and the same code
+// with the tail calls hidden behind nyan (which returns its only argument
+// as-is), and checks that both leave the expected result
+func boiSameWithoutTailCalls(t *testing.T, name, code, expected string) {
+	t.Helper()
+	notTail := strings.ReplaceAll(code, "boi: exit ["+name+" ", "boi: exit [nyan ["+name+" ")
+	notTail = strings.ReplaceAll(notTail, "] boi\n    BOI\nBOI", "]] boi\n    BOI\nBOI")
+	if notTail == code {
+		t.Fatal("didn't find a tail call to hide")
+	}
+	for _, version := range []string{code, notTail} {
+		boi, err := runBoi(t, version)
+		if err != nil {
+			t.Fatalf("%v\n%s", err, version)
+		}
+		result, _ := boi.context.Get("result")
+		if string(result.data) != expected {
+			t.Errorf("result is %q, expected %q\n%s", result.data, expected, version)
+		}
+	}
+}
+
+func TestTailCallsDontGrowTheStack(t *testing.T) {
+	// Far more than the default max call depth
+	boi, err := runBoi(t, `
+oh count n acc boi
+    boi: exit boi:acc boi
+    boi? < [int 0] boi:n boi
+        boi: exit [count [- boi:n [int 1]] [+ boi:acc [int 2]]] boi
+    BOI
+BOI
+boi: result [count [int 20000] [int 0]] boi
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := boiIntVar(t, boi, "result"); got != 40000 {
+		t.Errorf("result = %d, expected 40000", got)
+	}
+}
+
+func TestTailCallsKeepClosures(t *testing.T) {
+	// Each run makes a closure over its own n
+	boiSameWithoutTailCalls(t, "build", `
+boi! scoping lexical boi
+oh build n fns boi
+    oh boi
+        boi: exit [dec boi:n] boi
+    BOI
+    boi! list-push fns ret:exit boi
+    boi: exit boi:fns boi
+    boi? < [int 0] boi:n boi
+        boi: exit [build [- boi:n [int 1]] boi:fns] boi
+    BOI
+BOI
+boi: result "" boi
+foreach f [build [int 2] [list]] boi
+    boi: result [nyan boi:result [boi:f]] boi
+BOI
+`, "210")
+}
+
+func TestTailCallsKeepDynamicScope(t *testing.T) {
+	// A nested call sees its caller's variables, even ones local to a boi?
+	boiSameWithoutTailCalls(t, "walk", `
+oh walk n boi
+    boi! icanhas marker boi
+    boi: exit ret:exists boi
+    boi? == boi:n [int 1] boi
+        boi! local marker here boi
+        boi: exit [walk [int 0]] boi
+    BOI
+BOI
+boi: result [walk [int 1]] boi
+`, "true")
+
+	// ...but not its caller's arguments
+	boiSameWithoutTailCalls(t, "walk", `
+oh walk boi
+    boi! icanhas arg.1 boi
+    boi: exit ret:exists boi
+    boi? == boi:arg.0 go boi
+        boi: exit [walk stop] boi
+    BOI
+BOI
+boi: result [walk go extra] boi
+`, "false")
+}
+
+func TestTailCallsPassFunctions(t *testing.T) {
+	// A function made by one run is still there for the next
+	boiSameWithoutTailCalls(t, "run", `
+oh run n f boi
+    boi: exit [boi:f] boi
+    boi? < [int 0] boi:n boi
+        oh boi
+            boi: exit made boi
+        BOI
+        boi: made ret:exit boi
+        boi: exit [run [- boi:n [int 1]] boi:made] boi
+    BOI
+BOI
+oh first boi
+    boi: exit first boi
+BOI
+boi: result [run [int 3] first] boi
+`, "made")
+}
+
+func TestTimeoutStopsLoopsWithoutStatements(t *testing.T) {
+	// Neither of these runs a statement of its own on every iteration
+	for _, code := range []string{`
+oh spin n boi
+    boi: exit [spin boi:n] boi
+BOI
+boi! spin [int 0] boi
+`, `
+foreach i [int 0] [int 1000000000000] boi
+BOI
+`} {
+		_, err := runBoi(t, code, func(boi *BoiInterpreter) {
+			boi.SetTimeout(50 * time.Millisecond)
+		})
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("expected a timeout, got %v\n%s", err, code)
+		}
+	}
+}
+
+func TestSettingArgumentsStaysInFunction(t *testing.T) {
+	boi, err := runBoi(t, `
+oh inner boi
+    boi: arg.1 changed boi
+    boi: exit boi:arg.1 boi
+BOI
+oh outer boi
+    boi: exit [nyan [inner only-one] " " boi:arg.1] boi
+BOI
+boi: result [outer a b] boi
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// inner sees its own arg.1, and outer's is left alone
+	if result, _ := boi.context.Get("result"); string(result.data) != "changed b" {
+		t.Errorf("result = %q, expected \"changed b\"", result.data)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// Digests are returned as raw bytes; use hex-encode to print them.
+
+// boiHashes are the hash functions that can be used by name
+var boiHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// boiHashFunc makes the function for one of boiHashes, which returns the
+// digest of all of its parameters joined together
+func boiHashFunc(name string) BoiGoFunc {
+	return func(context *BoiContext, args []BoiVar) (BoiVar, error) {
+		h := boiHashes[name]()
+		for _, arg := range args {
+			h.Write(arg.data)
+		}
+		return BoiVar{h.Sum(nil)}, nil
+	}
+}
+
+// BoiFuncHMAC takes the name of a hash (ex: sha256), a key and a message,
+// and returns the message's HMAC
+func BoiFuncHMAC(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 3 {
+		return BoiVar{}, errors.New("hmac requires 3 parameters")
+	}
+	newHash, exists := boiHashes[string(args[0].data)]
+	if !exists {
+		return BoiVar{}, fmt.Errorf("hmac: unknown hash '%s'", args[0].data)
+	}
+	mac := hmac.New(newHash, args[1].data)
+	mac.Write(args[2].data)
+	return BoiVar{mac.Sum(nil)}, nil
+}
+
+// BoiFuncSecureEquals is like == for two values, but takes the same time
+// no matter where they differ, so it's safe for checking signatures
+func BoiFuncSecureEquals(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("secure-equals requires 2 parameters")
+	}
+	return boiBool(
+		subtle.ConstantTimeCompare(args[0].data, args[1].data) == 1,
+	), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// These functions read from the interpreter's input, which is standard
+// input unless it was changed with SetInput. When there's nothing left,
+// has-input returns false, so reading every line looks like this:
+//
+//	bloop has-input boi
+//	    boi, [upper [read-line]] boi
+//	BOI
+
+// BoiFuncReadLine returns the next line of input, without the line ending.
+// It sets "exists" to false if there was no line left to read.
+type BoiFuncReadLine struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncReadLine) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("read-line doesn't take parameters")
+	}
+	line, err := f.interpreter.stdin.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return BoiVar{}, err
+	}
+	context.variables["exists"] = boiBool(len(line) > 0)
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+		if n := len(line); n > 0 && line[n-1] == '\r' {
+			line = line[:n-1]
+		}
+	}
+	return BoiVar{line}, nil
+}
+
+// BoiFuncReadAll returns everything that's left of the input
+type BoiFuncReadAll struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncReadAll) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("read-all doesn't take parameters")
+	}
+	data, err := io.ReadAll(f.interpreter.stdin)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	return BoiVar{data}, nil
+}
+
+// BoiFuncReadBytes returns up to the given number of bytes (an integer)
+// of input. It only returns fewer when the input runs out.
+type BoiFuncReadBytes struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncReadBytes) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("read-bytes requires 1 parameter")
+	}
+	n, err := boiVarToIndex(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	// Only as much memory as the input really has is used, however many
+	// bytes are asked for
+	data, err := io.ReadAll(io.LimitReader(f.interpreter.stdin, int64(n)))
+	if err != nil {
+		return BoiVar{}, err
+	}
+	return BoiVar{data}, nil
+}
+
+// BoiFuncHasInput returns true until the input runs out
+type BoiFuncHasInput struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncHasInput) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("has-input doesn't take parameters")
+	}
+	_, err := f.interpreter.stdin.Peek(1)
+	if err != nil && err != io.EOF {
+		return BoiVar{}, err
+	}
+	return boiBool(err == nil), nil
+}
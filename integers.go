@@ -2,10 +2,9 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
-	"math/rand"
 	"strconv"
-	"time"
 )
 
 type BoiFuncInt struct {
@@ -45,8 +44,10 @@ func (f BoiFuncAdd) Do(args []BoiVar) error {
 	sum := new(big.Int)
 	sum.SetUint64(0)
 	for _, arg := range args {
-		tmp := new(big.Int)
-		tmp.SetBytes(arg.data)
+		tmp, err := boiVarToInt(arg)
+		if err != nil {
+			return fmt.Errorf("+: %v", err)
+		}
 		sum.Add(sum, tmp)
 	}
 
@@ -62,11 +63,15 @@ func (f BoiFuncSub) Do(args []BoiVar) error {
 	context := f.interpreter.subContext()
 	defer f.interpreter.returnContext()
 
-	sum := new(big.Int)
-	sum.SetBytes(args[0].data)
+	sum, err := boiVarToInt(args[0])
+	if err != nil {
+		return fmt.Errorf("-: %v", err)
+	}
 	for _, arg := range args[1:] {
-		tmp := new(big.Int)
-		tmp.SetBytes(arg.data)
+		tmp, err := boiVarToInt(arg)
+		if err != nil {
+			return fmt.Errorf("-: %v", err)
+		}
 		sum.Sub(sum, tmp)
 	}
 
@@ -82,11 +87,15 @@ func (f BoiFuncDiv) Do(args []BoiVar) error {
 	context := f.interpreter.subContext()
 	defer f.interpreter.returnContext()
 
-	sum := new(big.Int)
-	sum.SetBytes(args[0].data)
+	sum, err := boiVarToInt(args[0])
+	if err != nil {
+		return fmt.Errorf("/: %v", err)
+	}
 	for _, arg := range args[1:] {
-		tmp := new(big.Int)
-		tmp.SetBytes(arg.data)
+		tmp, err := boiVarToInt(arg)
+		if err != nil {
+			return fmt.Errorf("/: %v", err)
+		}
 		sum.Div(sum, tmp)
 	}
 
@@ -105,8 +114,10 @@ func (f BoiFuncMul) Do(args []BoiVar) error {
 	sum := new(big.Int)
 	sum = sum.SetUint64(1)
 	for _, arg := range args {
-		tmp := new(big.Int)
-		tmp.SetBytes(arg.data)
+		tmp, err := boiVarToInt(arg)
+		if err != nil {
+			return fmt.Errorf("*: %v", err)
+		}
 		sum.Mul(sum, tmp)
 	}
 
@@ -123,9 +134,11 @@ func (f BoiFuncDec) Do(args []BoiVar) error {
 	defer f.interpreter.returnContext()
 
 	value := new(big.Int)
-	value = value.SetUint64(0)
 	if len(args) > 0 {
-		value = value.SetBytes(args[0].data)
+		var err error
+		if value, err = boiVarToInt(args[0]); err != nil {
+			return fmt.Errorf("dec: %v", err)
+		}
 	}
 
 	output := []byte(value.String())
@@ -139,9 +152,14 @@ func BoiFuncLess(context *BoiContext, args []BoiVar) (BoiVar, error) {
 		return BoiVar{}, errors.New("< requires at least 2 parameters")
 	}
 	for i := 0; i < len(args)-1; i++ {
-		a, b := new(big.Int), new(big.Int)
-		a = a.SetBytes(args[i].data)
-		b = b.SetBytes(args[i+1].data)
+		a, err := boiVarToInt(args[i])
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("<: %v", err)
+		}
+		b, err := boiVarToInt(args[i+1])
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("<: %v", err)
+		}
 		if a.Cmp(b) >= 0 {
 			return BoiVar{[]byte("false")}, nil
 		}
@@ -149,13 +167,18 @@ func BoiFuncLess(context *BoiContext, args []BoiVar) (BoiVar, error) {
 	return BoiVar{[]byte("true")}, nil
 }
 
-func BoiFuncIsEven(context *BoiContext, args []BoiVar) (BoiVar, error) {
+type BoiFuncIsEven struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncIsEven) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
 	if len(args) != 1 {
 		return BoiVar{}, errors.New("IsEven can only take one value (for now)")
 	}
-	// always seed random
-	rand.Seed(time.Now().UTC().UnixNano())
-	probabilityOfWrongAnswer := rand.Intn(100)
+	random := f.interpreter.random
+	probabilityOfWrongAnswer := random.Intn(100)
 
 	value := new(big.Int)
 	value = value.SetBytes(args[0].data)
@@ -164,7 +187,7 @@ func BoiFuncIsEven(context *BoiContext, args []BoiVar) (BoiVar, error) {
 
 	even := valueInt%2 == 0
 
-	if rand.Intn(100) < probabilityOfWrongAnswer {
+	if random.Intn(100) < probabilityOfWrongAnswer {
 		even = !even
 	}
 
@@ -176,3 +199,33 @@ func BoiFuncIsEven(context *BoiContext, args []BoiVar) (BoiVar, error) {
 	return BoiVar{[]byte{byte(probabilityOfEven)}}, nil
 
 }
+
+// boiVarToInt reads an integer value. Decimals, lists and dicts aren't
+// integers, even though they're bytes too; inty turns a decimal into one.
+func boiVarToInt(v BoiVar) (*big.Int, error) {
+	switch true {
+	case boiIsDecimal(v):
+		return nil, errors.New("value is a decimal, not an integer (use inty)")
+	case boiIsList(v), boiIsDict(v), boiIsJSONNull(v):
+		return nil, errors.New("value is not a number")
+	}
+	return new(big.Int).SetBytes(v.data), nil
+}
+
+// boiVarToIndex reads an integer value as a Go int, for functions that
+// take positions or counts
+func boiVarToIndex(v BoiVar) (int, error) {
+	value := new(big.Int)
+	value.SetBytes(v.data)
+	if !value.IsInt64() || value.Int64() > int64(^uint(0)>>1) {
+		return 0, errors.New("integer is too big, boi")
+	}
+	return int(value.Int64()), nil
+}
+
+// boiIndexToVar is the opposite of boiVarToIndex
+func boiIndexToVar(i int) BoiVar {
+	value := new(big.Int)
+	value.SetInt64(int64(i))
+	return BoiVar{value.Bytes()}
+}
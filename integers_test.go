@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntegerFunctionsRejectOtherValues(t *testing.T) {
+	tests := map[string]string{
+		"[+ [float 1.5] [int 1]]": "+: value is a decimal",
+		"[- [int 3] [float 1]]":   "-: value is a decimal",
+		"[* [int 3] [list 1 2]]":  "*: value is not a number",
+		"[/ [dict a b] [int 2]]":  "/: value is not a number",
+		"[< [int 1] [float 2]]":   "<: value is a decimal",
+		"[dec [float 1.5]]":       "dec: value is a decimal",
+	}
+	for call, expected := range tests {
+		_, err := runBoi(t, "boi: x "+call+" boi\n")
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected %q, got %v", call, expected, err)
+		}
+	}
+
+	boi, err := runBoi(t, "boi: x [+ [inty [float 1.5]] [int 1]] boi\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := boiIntVar(t, boi, "x"); x != 2 {
+		t.Errorf("inty then + gave %d, expected 2", x)
+	}
+}
+
+func TestForeachRangeRejectsOtherValues(t *testing.T) {
+	_, err := runBoi(t, "foreach i [int 0] [float 2] boi\nBOI\n",
+		func(boi *BoiInterpreter) { boi.SetTimeout(time.Second) },
+	)
+	if err == nil || !strings.Contains(err.Error(), "foreach: value is a decimal") {
+		t.Errorf("expected foreach to reject a decimal, got %v", err)
+	}
+}
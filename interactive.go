@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,7 +15,7 @@ import (
 func boiInteractive() {
 	userin := bufio.NewReader(os.Stdin)
 
-	var lastContext *BoiContext = nil
+	var lex *BoiInterpreter = nil
 
 	for {
 		text, err := userin.ReadBytes('\n')
@@ -22,28 +24,52 @@ func boiInteractive() {
 			break
 		}
 
-		lex := NewBoiInterpreter(text)
-		if lastContext != nil {
-			lex.context = lastContext
+		if lex == nil {
+			lex = NewBoiInterpreter(text)
+			boiConfigure(lex)
+			// Share the buffer, or it would eat the next lines of code
+			lex.SetInput(userin)
+		} else {
+			lex.Load(text)
 		}
 		if err := lex.Run(); err != nil {
 			boiError(err)
 			continue
 		}
-		lastContext = lex.context
 	}
 }
 
+// BoiSlackTimeout is how long a Slack message's code can run, unless the
+// -timeout flag says otherwise
+const BoiSlackTimeout = 10 * time.Second
+
 func boiSlackServer(hostname string) {
 
-	var lastContext *BoiContext = nil
+	var lex *BoiInterpreter = nil
+
+	// gin handles requests at the same time, but there's only one
+	// interpreter (and one os.Stdout), so they take turns
+	var lexLock sync.Mutex
 
 	r := gin.Default()
 	r.POST("/", func(c *gin.Context) {
 		text := c.PostForm("text")
-		lex := NewBoiInterpreter([]byte(text))
-		if lastContext != nil {
-			lex.context = lastContext
+		lexLock.Lock()
+		defer lexLock.Unlock()
+		if lex == nil {
+			lex = NewBoiInterpreter([]byte(text))
+			boiConfigure(lex)
+			// Whoever is on Slack doesn't get to touch this server's files
+			// or run programs on it, or keep it busy forever
+			lex.Revoke(BoiCapFilesystem | BoiCapExec)
+			if lex.timeout == 0 {
+				lex.SetTimeout(BoiSlackTimeout)
+			}
+			lex.SetInput(bytes.NewReader(nil))
+			// The real environment has secrets like the signing key
+			lex.SetEnvironment(map[string]string{})
+		} else {
+			lex.Load([]byte(text))
 		}
 
 		// === + Terrible Hack Start ===
@@ -85,7 +111,6 @@ func boiSlackServer(hostname string) {
 				"in_channel", output,
 			})
 		}
-		lastContext = lex.context
 	})
 	r.Run(hostname)
 }
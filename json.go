@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// JSON values turn into Boi values like this (see the README):
+//
+//	object  ->  dict
+//	array   ->  list
+//	string  ->  the string
+//	number  ->  decimal
+//	true    ->  true
+//	false   ->  false
+//	null    ->  the value json-null returns
+//
+// Going the other way works the same, and anything else (including an
+// integer, which is only bytes) becomes a string. Use floaty to write an
+// integer as a number.
+
+// boiJSONNull is what null reads as. It's a value of its own so that it
+// can be written back out as null.
+var boiJSONNull = []byte("\x00boi:null\x00")
+
+func boiIsJSONNull(v BoiVar) bool {
+	return bytes.Equal(v.data, boiJSONNull)
+}
+
+func boiParseJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("more than one JSON value")
+	}
+	return v, nil
+}
+
+func boiFromJSON(v interface{}) BoiVar {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		dict := map[string]BoiVar{}
+		for key, value := range v {
+			dict[key] = boiFromJSON(value)
+		}
+		return boiDictToVar(dict)
+	case []interface{}:
+		items := []BoiVar{}
+		for _, value := range v {
+			items = append(items, boiFromJSON(value))
+		}
+		return boiListToVar(items)
+	case string:
+		return BoiVar{[]byte(v)}
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(v.String()); ok {
+			return boiRatToVar(r)
+		}
+		return BoiVar{[]byte(v.String())}
+	case bool:
+		return boiBool(v)
+	}
+	return BoiVar{append([]byte{}, boiJSONNull...)}
+}
+
+func boiToJSON(v BoiVar) (interface{}, error) {
+	switch true {
+	case boiIsDict(v):
+		dict, err := boiVarToDict(v)
+		if err != nil {
+			return nil, err
+		}
+		object := map[string]interface{}{}
+		for key, value := range dict {
+			if object[key], err = boiToJSON(value); err != nil {
+				return nil, err
+			}
+		}
+		return object, nil
+	case boiIsList(v):
+		items, err := boiVarToList(v)
+		if err != nil {
+			return nil, err
+		}
+		array := []interface{}{}
+		for _, item := range items {
+			value, err := boiToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, nil
+	case boiIsDecimal(v):
+		r, err := boiVarToRat(v)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(boiFormatRat(r)), nil
+	case boiIsJSONNull(v):
+		return nil, nil
+	case string(v.data) == "true":
+		return true, nil
+	case string(v.data) == "false":
+		return false, nil
+	}
+	return string(v.data), nil
+}
+
+// BoiFuncJSONNull returns the value that json-stringify writes as null
+func BoiFuncJSONNull(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("json-null doesn't take parameters")
+	}
+	return BoiVar{append([]byte{}, boiJSONNull...)}, nil
+}
+
+// BoiFuncJSONParse reads a JSON document into Boi values
+func BoiFuncJSONParse(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("json-parse requires 1 parameter")
+	}
+	v, err := boiParseJSON(args[0].data)
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("json-parse: %v", err)
+	}
+	return boiFromJSON(v), nil
+}
+
+// BoiFuncJSONStringify writes a value as JSON
+func BoiFuncJSONStringify(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("json-stringify requires 1 parameter")
+	}
+	v, err := boiToJSON(args[0])
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("json-stringify: %v", err)
+	}
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return BoiVar{}, fmt.Errorf("json-stringify: %v", err)
+	}
+	return BoiVar{bytes.TrimSuffix(output.Bytes(), []byte("\n"))}, nil
+}
+
+// BoiFuncJSONGet pulls one value out of a JSON document using a path like
+// "users.0.name", where numbers pick elements of arrays. Like dict-get, it
+// sets "exists" to say if there was anything there.
+func BoiFuncJSONGet(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("json-get requires 2 parameters")
+	}
+	v, err := boiParseJSON(args[0].data)
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("json-get: %v", err)
+	}
+	path := string(args[1].data)
+	exists := true
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			if v, exists = boiJSONStep(v, key); !exists {
+				break
+			}
+		}
+	}
+	context.variables["exists"] = boiBool(exists)
+	if !exists {
+		return BoiVar{[]byte{}}, nil
+	}
+	return boiFromJSON(v), nil
+}
+
+// boiJSONStep looks up a key in an object, or an index in an array
+func boiJSONStep(v interface{}, key string) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		value, exists := v[key]
+		return value, exists
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(v) {
+			return nil, false
+		}
+		return v[i], true
+	}
+	return nil, false
+}
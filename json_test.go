@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestJSONStringify(t *testing.T) {
+	tests := map[string]string{
+		`[json-parse "{\"a\":1,\"b\":null,\"c\":[true,-2.5e1]}"]`: `{"a":1,"b":null,"c":[true,-25]}`,
+		`[dict f [float 1.5] n [floaty [int 30]] s [int 30]]`:     `{"f":1.5,"n":30,"s":"\u001e"}`,
+		`[list false [json-null] "" [float 1/4]]`:                 `[false,null,"",0.25]`,
+	}
+	for value, expected := range tests {
+		boi, err := runBoi(t, "boi: x [json-stringify "+value+"] boi\n")
+		if err != nil {
+			t.Errorf("%s: %v", value, err)
+			continue
+		}
+		if x, _ := boi.context.Get("x"); string(x.data) != expected {
+			t.Errorf("%s: got %s, expected %s", value, x.data, expected)
+		}
+	}
+}
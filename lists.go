@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// A list is still just a BoiVar. Its bytes start with boiListMagic, and
+// each element follows as a uvarint length and then the element's bytes.
+// The magic prefix makes lists self-describing, so say can print them and
+// elements can be lists themselves.
+var boiListMagic = []byte("\x00boi:list\x00")
+
+func boiIsList(v BoiVar) bool {
+	return bytes.HasPrefix(v.data, boiListMagic)
+}
+
+func boiListToVar(items []BoiVar) BoiVar {
+	data := append([]byte{}, boiListMagic...)
+	lenBuf := make([]byte, binary.MaxVarintLen64)
+	for _, item := range items {
+		n := binary.PutUvarint(lenBuf, uint64(len(item.data)))
+		data = append(data, lenBuf[:n]...)
+		data = append(data, item.data...)
+	}
+	return BoiVar{data}
+}
+
+func boiVarToList(v BoiVar) ([]BoiVar, error) {
+	if !boiIsList(v) {
+		return nil, errors.New("value is not a list")
+	}
+	return boiDecodeEntries(v.data[len(boiListMagic):])
+}
+
+// boiDecodeEntries reads length-prefixed entries until it runs out of bytes
+func boiDecodeEntries(data []byte) ([]BoiVar, error) {
+	items := []BoiVar{}
+	for len(data) > 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return nil, errors.New("corrupted list, boi")
+		}
+		data = data[n:]
+		items = append(items, BoiVar{append([]byte{}, data[:length]...)})
+		data = data[length:]
+	}
+	return items, nil
+}
+
+// boiDisplay returns what say should print for a value. Most values are
+// printed as-is, but lists and dicts are shown with their elements, and
+// decimals are written out like fdec does. JSON null is shown as null.
+func boiDisplay(v BoiVar) []byte {
+	if boiIsDict(v) {
+		return boiDisplayDict(v)
+	}
+	if boiIsDecimal(v) {
+		if r, err := boiVarToRat(v); err == nil {
+			return []byte(boiFormatRat(r))
+		}
+		return v.data
+	}
+	if boiIsJSONNull(v) {
+		return []byte("null")
+	}
+	if !boiIsList(v) {
+		return v.data
+	}
+	items, err := boiVarToList(v)
+	if err != nil {
+		return v.data
+	}
+	output := []byte("[")
+	for i, item := range items {
+		if i > 0 {
+			output = append(output, ' ')
+		}
+		output = append(output, boiDisplay(item)...)
+	}
+	return append(output, ']')
+}
+
+// boiGetListVar looks up a variable that should hold a list, for the
+// functions which change a list in place. A variable that doesn't exist
+// yet is an empty list.
+func boiGetListVar(context *BoiContext, name string) ([]BoiVar, error) {
+	v, exists := context.parentCtx.Get(name)
+	if !exists {
+		return []BoiVar{}, nil
+	}
+	items, err := boiVarToList(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return items, nil
+}
+
+// BoiFuncList makes a list out of its parameters
+func BoiFuncList(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	return boiListToVar(args), nil
+}
+
+// BoiFuncListPush adds values to the end of the list stored in the named
+// variable, and returns the new list.
+func BoiFuncListPush(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 1 {
+		return BoiVar{}, errors.New("list-push requires at least 1 parameter")
+	}
+	name := string(args[0].data)
+	items, err := boiGetListVar(context, name)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	list := boiListToVar(append(items, args[1:]...))
+	context.parentCtx.Set(name, list)
+	return list, nil
+}
+
+// BoiFuncListPop removes the last value from the list stored in the named
+// variable, and returns it.
+func BoiFuncListPop(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("list-pop requires 1 parameter")
+	}
+	name := string(args[0].data)
+	items, err := boiGetListVar(context, name)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	if len(items) == 0 {
+		return BoiVar{}, fmt.Errorf("list-pop: %s is empty", name)
+	}
+	context.parentCtx.Set(name, boiListToVar(items[:len(items)-1]))
+	return items[len(items)-1], nil
+}
+
+func BoiFuncListGet(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("list-get requires 2 parameters")
+	}
+	items, err := boiVarToList(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	i, err := boiVarToIndex(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	if i >= len(items) {
+		return BoiVar{}, fmt.Errorf(
+			"list-get %d out of range for length %d", i, len(items),
+		)
+	}
+	return items[i], nil
+}
+
+// BoiFuncListSet replaces the value at a position in the list stored in
+// the named variable, and returns the new list.
+func BoiFuncListSet(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 3 {
+		return BoiVar{}, errors.New("list-set requires 3 parameters")
+	}
+	name := string(args[0].data)
+	items, err := boiGetListVar(context, name)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	i, err := boiVarToIndex(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	if i >= len(items) {
+		return BoiVar{}, fmt.Errorf(
+			"list-set %d out of range for length %d", i, len(items),
+		)
+	}
+	items[i] = args[2]
+	list := boiListToVar(items)
+	context.parentCtx.Set(name, list)
+	return list, nil
+}
+
+func BoiFuncListLen(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("list-len requires 1 parameter")
+	}
+	items, err := boiVarToList(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	return boiIndexToVar(len(items)), nil
+}
+
+// BoiFuncListSlice works like slice, but counts elements instead of bytes
+func BoiFuncListSlice(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return BoiVar{}, errors.New("list-slice requires 2 or 3 parameters")
+	}
+	items, err := boiVarToList(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	start, err := boiVarToIndex(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	end := len(items)
+	if len(args) > 2 {
+		if end, err = boiVarToIndex(args[2]); err != nil {
+			return BoiVar{}, err
+		}
+	}
+	if start > end || end > len(items) {
+		return BoiVar{}, fmt.Errorf(
+			"list-slice [%d:%d] out of range for length %d",
+			start, end, len(items),
+		)
+	}
+	return boiListToVar(items[start:end]), nil
+}
+
+// BoiFuncListNext is made for bloop. It takes the first value out of the
+// list stored in the variable named by the first parameter, stores it in
+// the variable named by the second parameter, and returns true. Once the
+// list is empty it returns false instead.
+//
+//	bloop list-next items item boi
+//	    boi, boi:item boi
+//	BOI
+func BoiFuncListNext(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("list-next requires 2 parameters")
+	}
+	name := string(args[0].data)
+	items, err := boiGetListVar(context, name)
+	if err != nil {
+		return BoiVar{}, err
+	}
+	if len(items) == 0 {
+		return boiBool(false), nil
+	}
+	context.parentCtx.Set(name, boiListToVar(items[1:]))
+	context.parentCtx.Set(string(args[1].data), items[0])
+	return boiBool(true), nil
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"regexp"
+	"strings"
+	"time"
+	"weak"
 )
 
 // These type definitions make it possible to
@@ -21,21 +29,73 @@ func boiError(boiInputs ...interface{}) {
 	fmt.Println(", boi\033[0m")
 }
 
+// Command line flags
+var (
+	boiFlagSeed = flag.Int64(
+		"seed", 0, "seed for random numbers (default: the current time)",
+	)
+	boiFlagMaxDepth = flag.Int(
+		"max-depth", BoiDefaultMaxCallDepth,
+		"maximum function call depth (0 for no limit)",
+	)
+	boiFlagFilesystem = flag.Bool(
+		"fs", false, "allow scripts to read and write files",
+	)
+	boiFlagFilesystemRoot = flag.String(
+		"fs-root", "", "keep file access inside this directory (implies -fs)",
+	)
+	boiFlagExec = flag.Bool(
+		"exec", false, "allow scripts to run other programs",
+	)
+	boiFlagTimeout = flag.Duration(
+		"timeout", 0, "stop scripts that run longer than this (ex: 30s)",
+	)
+)
+
+// boiConfigure applies the command line flags that were given to an
+// interpreter
+func boiConfigure(boi *BoiInterpreter) {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			boi.SeedRandom(*boiFlagSeed)
+		case "max-depth":
+			boi.SetMaxCallDepth(*boiFlagMaxDepth)
+		case "fs":
+			if *boiFlagFilesystem {
+				boi.Grant(BoiCapFilesystem)
+			}
+		case "fs-root":
+			boi.Grant(BoiCapFilesystem)
+			boi.SetFilesystemRoot(*boiFlagFilesystemRoot)
+		case "exec":
+			if *boiFlagExec {
+				boi.Grant(BoiCapExec)
+			}
+		case "timeout":
+			boi.SetTimeout(*boiFlagTimeout)
+		}
+	})
+}
+
 func main() {
-	boiArgs := os.Args[1:] // boi
+	flag.Parse()
+	boiArgs := flag.Args() // boi
 
 	var reader io.Reader
 
 	if len(boiArgs) < 1 {
 		boiInteractive()
+		return
 	} else if boiArgs[0] == "/slack" {
 		hostname := ""
 		if len(boiArgs) > 1 {
 			hostname = boiArgs[1]
 		}
 		boiSlackServer(hostname)
+		return
 	} else if boiArgs[0] == "-" {
-		reader = os.Stdout
+		reader = os.Stdin
 	} else {
 		//
 		boiFilename := boiArgs[0]
@@ -49,6 +109,7 @@ func main() {
 		reader, err = os.Open(boiFilename)
 		if err != nil {
 			boiError(err)
+			return
 		}
 
 	}
@@ -66,6 +127,7 @@ func boiBoi(reader io.Reader) error {
 	}
 
 	lex := NewBoiInterpreter(code)
+	boiConfigure(lex)
 	if err := lex.Run(); err != nil {
 		return err
 	}
@@ -77,6 +139,29 @@ type BoiVar struct {
 	data []byte
 }
 
+// IsTrue implements the truth semantics described in the README. Note that
+// a variable which doesn't exist is also false, but that's up to the caller
+// to check.
+func (v BoiVar) IsTrue() bool {
+	switch true {
+	case len(v.data) == 0:
+		fallthrough
+	case len(v.data) == 1 && v.data[0] == 0:
+		fallthrough
+	case string(v.data) == "false":
+		return false
+	}
+	return true
+}
+
+// boiBool returns the canonical "true" or "false" value
+func boiBool(b bool) BoiVar {
+	if b {
+		return BoiVar{[]byte("true")}
+	}
+	return BoiVar{[]byte("false")}
+}
+
 const (
 	BoiTokenValue = 1 // A string
 	BoiTokenVar   = 2
@@ -84,6 +169,20 @@ const (
 	BoiTokenCall  = 4
 )
 
+// BoiDefaultMaxCallDepth is the call depth limit unless the command line
+// or SetMaxCallDepth says otherwise
+const BoiDefaultMaxCallDepth = 1000
+
+// Capabilities are things a script can only do if the interpreter was
+// given permission with Grant, since they reach outside of the script
+const (
+	// BoiCapFilesystem allows reading and writing files
+	BoiCapFilesystem IntyBoi = 1 << iota
+
+	// BoiCapExec allows running other programs
+	BoiCapExec
+)
+
 const (
 	// BoiStateStatement means we're expecting a statement
 	BoiStateStatement IntyBoi = 0 // boi
@@ -116,24 +215,52 @@ type BoiContext struct {
 	variables map[string]BoiVar
 	parentCtx *BoiContext
 	returnCtx *BoiContext
+
+	// function is set on the context created for a call to an oh
+	// function, and marks where that call's arguments end
+	function *BoiStatementsFunction
+
+	// anonymous is only set on the root context (see
+	// boiAnonymousFunctions)
+	anonymous *boiAnonymousFunctions
 }
 
 func (ctx *BoiContext) Call(fname string, args []BoiVar) error {
-	f, exists := ctx.functions[fname]
-	if !exists {
-		if ctx.parentCtx == nil {
-			return fmt.Errorf("call to undefined function %s", fname)
-		} else {
-			return ctx.parentCtx.Call(fname, args)
+	// Lookup is a loop rather than recursion on parentCtx because the
+	// function runs from here; recursing would leave a Go stack frame
+	// behind for every context between the caller and the function.
+	if f, exists := ctx.Lookup(fname); exists {
+		return f.Do(args)
+	}
+	return fmt.Errorf("call to undefined function %s", fname)
+}
+
+// Lookup finds the function that a call to fname would run
+func (ctx *BoiContext) Lookup(fname string) (BoiFunc, bool) {
+	tryContext := ctx
+	for {
+		if f, exists := tryContext.functions[fname]; exists {
+			return f, true
 		}
+		if tryContext.parentCtx == nil {
+			break
+		}
+		tryContext = tryContext.parentCtx
 	}
-	return f.Do(args)
+	// Anonymous functions can be called from anywhere, for as long as
+	// something holds on to them
+	return tryContext.anonymous.lookup(fname)
 }
 
 func (ctx *BoiContext) Set(vname string, value BoiVar) error {
 	tryContext := ctx
 	_, exists := tryContext.variables[vname]
 	for !exists {
+		// A function can't change its caller's arguments, just as it
+		// can't see them (see Get)
+		if tryContext.function != nil && strings.HasPrefix(vname, "arg.") {
+			break
+		}
 		if tryContext.parentCtx == nil {
 			break
 		} else {
@@ -141,17 +268,41 @@ func (ctx *BoiContext) Set(vname string, value BoiVar) error {
 		}
 		_, exists = tryContext.variables[vname]
 	}
-	if exists {
-		tryContext.variables[vname] = value
-	} else {
-		ctx.variables[vname] = value
+	if !exists {
+		tryContext = ctx
 	}
+	tryContext.variables[vname] = value
+	tryContext.adopt(value)
 	return nil
 }
 
+// Unset deletes a variable from the nearest context that has it, and
+// reports whether there was one to delete
+func (ctx *BoiContext) Unset(vname string) bool {
+	for tryContext := ctx; tryContext != nil; tryContext = tryContext.parentCtx {
+		if _, exists := tryContext.variables[vname]; exists {
+			delete(tryContext.variables, vname)
+			return true
+		}
+	}
+	return false
+}
+
+// Root returns the outermost context
+func (ctx *BoiContext) Root() *BoiContext {
+	for ctx.parentCtx != nil {
+		ctx = ctx.parentCtx
+	}
+	return ctx
+}
+
 func (ctx *BoiContext) Get(vname string) (BoiVar, bool) {
 	v, exists := ctx.variables[vname]
 	if !exists {
+		// Don't let a function see its caller's arguments
+		if ctx.function != nil && strings.HasPrefix(vname, "arg.") {
+			return BoiVar{}, false
+		}
 		if ctx.parentCtx == nil {
 			// TODO: Raise error if boi.context is strict context
 			return BoiVar{}, false
@@ -174,19 +325,70 @@ type BoiInterpreter struct {
 	rIsBoi    *regexp.Regexp
 
 	context *BoiContext
+
+	// anonymousCount is used to give each anonymous function a name
+	anonymousCount int
+
+	// lexicalScoping makes functions defined from now on capture the
+	// context they're defined in (see BoiStatementsFunction)
+	lexicalScoping bool
+
+	// random is where all randomness used by the script comes from
+	random *rand.Rand
+
+	// calls holds the names of the oh functions currently running, with
+	// the innermost last. evals counts the evals running. Together, they
+	// make up the call depth.
+	calls        []string
+	evals        int
+	maxCallDepth int
+
+	// capabilities holds the Boi capabilities that were granted
+	capabilities IntyBoi
+
+	// stdin is what read-line and friends read from
+	stdin *bufio.Reader
+
+	// env is the environment that scripts see instead of the real one,
+	// if it isn't nil
+	env map[string]string
+
+	// clock is where the time comes from, including for timeouts
+	clock BoiClock
+
+	// timeout limits how long Run can take, if it isn't zero. deadline
+	// is when the current Run has to be done by.
+	timeout  time.Duration
+	deadline time.Time
+
+	// regexCache holds the patterns compiled by the regex functions
+	regexCache map[string]*regexp.Regexp
+
+	// fsRoot is the directory that file paths are relative to, and that
+	// they can't leave. Empty means paths are used as they are.
+	fsRoot string
 }
 
 func NewBoiInterpreter(input []byte) *BoiInterpreter {
 	rootContext := &BoiContext{
 		map[string]BoiFunc{},
 		map[string]BoiVar{},
-		nil, nil,
+		nil, nil, nil,
+		&boiAnonymousFunctions{
+			map[string]weak.Pointer[BoiStatementsFunction]{}, 0,
+		},
 	}
 
 	boi := &BoiInterpreter{
-		input, 0, BoiStateStatement,
-		nil, nil, nil, nil,
-		rootContext,
+		input:   input,
+		pos:     0,
+		state:   BoiStateStatement,
+		context: rootContext,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		stdin:   bufio.NewReader(os.Stdin),
+		clock:   BoiSystemClock{},
+
+		maxCallDepth: BoiDefaultMaxCallDepth,
 	}
 	boi.rIsBoiVar = regexp.MustCompile("^boi:[A-Za-z][A-Za-z0-9]*")
 	boi.rIsRetVar = regexp.MustCompile("^ret:[A-Za-z][A-Za-z0-9]*")
@@ -201,6 +403,60 @@ func NewBoiInterpreter(input []byte) *BoiInterpreter {
 	boi.RegisterGoFunction("set", BoiFuncSet)
 	boi.RegisterGoFunction("icanhas", BoiFuncGet)
 	boi.RegisterGoFunction("nyan", BoiFuncCat)
+
+	// Strings
+	boi.RegisterGoFunction("len", BoiFuncLen)
+	boi.RegisterGoFunction("slice", BoiFuncSlice)
+	boi.RegisterGoFunction("index-of", BoiFuncIndexOf)
+	boi.RegisterGoFunction("split", BoiFuncSplit)
+	boi.RegisterGoFunction("join", BoiFuncJoin)
+	boi.RegisterGoFunction("replace", BoiFuncReplace)
+	boi.RegisterGoFunction("upper", BoiFuncUpper)
+	boi.RegisterGoFunction("lower", BoiFuncLower)
+	boi.RegisterGoFunction("trim", BoiFuncTrim)
+	boi.RegisterGoFunction("repeat", BoiFuncRepeat)
+	boi.RegisterGoFunction("reverse", BoiFuncReverse)
+	boi.RegisterGoFunction("byte-at", BoiFuncByteAt)
+	boi.RegisterGoFunction("from-byte", BoiFuncFromByte)
+
+	// Lists
+	boi.RegisterGoFunction("list", BoiFuncList)
+	boi.RegisterGoFunction("list-push", BoiFuncListPush)
+	boi.RegisterGoFunction("list-pop", BoiFuncListPop)
+	boi.RegisterGoFunction("list-get", BoiFuncListGet)
+	boi.RegisterGoFunction("list-set", BoiFuncListSet)
+	boi.RegisterGoFunction("list-len", BoiFuncListLen)
+	boi.RegisterGoFunction("list-slice", BoiFuncListSlice)
+	boi.RegisterGoFunction("list-next", BoiFuncListNext)
+
+	// Dicts
+	boi.RegisterGoFunction("dict", BoiFuncDict)
+	boi.RegisterGoFunction("dict-put", BoiFuncDictPut)
+	boi.RegisterGoFunction("dict-get", BoiFuncDictGet)
+	boi.RegisterGoFunction("dict-has", BoiFuncDictHas)
+	boi.RegisterGoFunction("dict-delete", BoiFuncDictDelete)
+	boi.RegisterGoFunction("dict-keys", BoiFuncDictKeys)
+	boi.RegisterGoFunction("dict-len", BoiFuncDictLen)
+
+	// Higher-order functions
+	boi.RegisterGoFunction("map", BoiFuncMap)
+	boi.RegisterGoFunction("filter", BoiFuncFilter)
+	boi.RegisterGoFunction("reduce", BoiFuncReduce)
+	boi.RegisterGoFunction("apply", BoiFuncApply)
+	boi.RegisterGoFunction("sort-by", BoiFuncSortBy)
+
+	// Comparisons and logic
+	boi.RegisterGoFunction("==", BoiFuncEqual)
+	boi.RegisterGoFunction("/=", BoiFuncNotEqual)
+	boi.RegisterGoFunction("before", BoiFuncBefore)
+	boi.RegisterGoFunction("after", BoiFuncAfter)
+	boi.RegisterGoFunction("has-prefix", BoiFuncHasPrefix)
+	boi.RegisterGoFunction("has-suffix", BoiFuncHasSuffix)
+	boi.RegisterGoFunction("contains", BoiFuncContains)
+	boi.RegisterGoFunction("not", BoiFuncNot)
+	boi.RegisterGoFunction("and", BoiFuncAnd)
+	boi.RegisterGoFunction("or", BoiFuncOr)
+
 	boi.context.functions["int"] = BoiFuncInt{boi}
 	boi.context.functions["+"] = BoiFuncAdd{boi}
 	boi.context.functions["-"] = BoiFuncSub{boi}
@@ -209,11 +465,115 @@ func NewBoiInterpreter(input []byte) *BoiInterpreter {
 	boi.context.functions["dec"] = BoiFuncDec{boi}
 	boi.RegisterGoFunction("<", BoiFuncLess)
 
+	// Decimals
+	boi.RegisterGoFunction("float", BoiFuncFloat)
+	boi.RegisterGoFunction("fdec", BoiFuncFdec)
+	boi.RegisterGoFunction("floaty", BoiFuncFloaty)
+	boi.RegisterGoFunction("inty", BoiFuncInty)
+	boi.RegisterGoFunction("f+", BoiFuncFloatyAdd)
+	boi.RegisterGoFunction("f-", BoiFuncFloatySub)
+	boi.RegisterGoFunction("f*", BoiFuncFloatyMul)
+	boi.RegisterGoFunction("f/", BoiFuncFloatyDiv)
+	boi.RegisterGoFunction("f<", BoiFuncFloatyLess)
+
+	// Scope
+	boi.RegisterGoFunctionStruct("scoping", BoiFuncScoping{boi})
+	boi.RegisterGoFunction("local", BoiFuncLocal)
+	boi.RegisterGoFunction("global", BoiFuncGlobal)
+	boi.RegisterGoFunction("unset", BoiFuncUnset)
+	boi.RegisterGoFunctionStruct("eval", BoiFuncEval{boi})
+
+	// Reflection
+	boi.RegisterGoFunction("has-func", BoiFuncHasFunc)
+	boi.RegisterGoFunction("vars", BoiFuncVars)
+	boi.RegisterGoFunction("funcs", BoiFuncFuncs)
+	boi.RegisterGoFunction("scope-depth", BoiFuncScopeDepth)
+	boi.RegisterGoFunctionStruct("func-name", BoiFuncFuncName{boi})
+
+	// Files (only with BoiCapFilesystem)
+	boi.registerFileFunction("read-file", 1, boiReadFile)
+	boi.registerFileFunction("write-file", 2, boiWriteFile)
+	boi.registerFileFunction("append-file", 2, boiAppendFile)
+	boi.registerFileFunction("file-exists", 1, boiFileExists)
+	boi.registerFileFunction("list-dir", 1, boiListDir)
+	boi.registerFileFunction("mkdir", 1, boiMkdir)
+	boi.registerFileFunction("remove", 1, boiRemove)
+	boi.registerFileFunction("stat", 1, boiStat)
+
+	// Input
+	boi.RegisterGoFunctionStruct("read-line", BoiFuncReadLine{boi})
+	boi.RegisterGoFunctionStruct("read-all", BoiFuncReadAll{boi})
+	boi.RegisterGoFunctionStruct("read-bytes", BoiFuncReadBytes{boi})
+	boi.RegisterGoFunctionStruct("has-input", BoiFuncHasInput{boi})
+
+	// Environment
+	boi.RegisterGoFunctionStruct("get-env", BoiFuncGetEnv{boi})
+	boi.RegisterGoFunctionStruct("set-env", BoiFuncSetEnv{boi})
+	boi.RegisterGoFunctionStruct("list-env", BoiFuncListEnv{boi})
+
+	// Programs (only with BoiCapExec)
+	boi.RegisterGoFunctionStruct("exec", BoiFuncExec{boi, false})
+	boi.RegisterGoFunctionStruct("exec-with-stdin", BoiFuncExec{boi, true})
+
+	// Encoding
+	boi.RegisterGoFunction("hex-encode", BoiFuncHexEncode)
+	boi.RegisterGoFunction("hex-decode", BoiFuncHexDecode)
+	boi.RegisterGoFunction("base64-encode",
+		boiBase64Encode("base64-encode", base64.StdEncoding))
+	boi.RegisterGoFunction("base64-decode",
+		boiBase64Decode("base64-decode", base64.StdEncoding))
+	boi.RegisterGoFunction("base64url-encode",
+		boiBase64Encode("base64url-encode", base64.URLEncoding))
+	boi.RegisterGoFunction("base64url-decode",
+		boiBase64Decode("base64url-decode", base64.URLEncoding))
+	boi.RegisterGoFunction("url-escape", BoiFuncURLEscape)
+	boi.RegisterGoFunction("url-unescape", BoiFuncURLUnescape)
+	boi.RegisterGoFunction("utf8-valid", BoiFuncUTF8Valid)
+	boi.RegisterGoFunction("rune-count", BoiFuncRuneCount)
+
+	// JSON
+	boi.RegisterGoFunction("json-parse", BoiFuncJSONParse)
+	boi.RegisterGoFunction("json-stringify", BoiFuncJSONStringify)
+	boi.RegisterGoFunction("json-get", BoiFuncJSONGet)
+	boi.RegisterGoFunction("json-null", BoiFuncJSONNull)
+
+	// Hashing
+	boi.RegisterGoFunction("md5", boiHashFunc("md5"))
+	boi.RegisterGoFunction("sha1", boiHashFunc("sha1"))
+	boi.RegisterGoFunction("sha256", boiHashFunc("sha256"))
+	boi.RegisterGoFunction("sha512", boiHashFunc("sha512"))
+	boi.RegisterGoFunction("hmac", BoiFuncHMAC)
+	boi.RegisterGoFunction("secure-equals", BoiFuncSecureEquals)
+
+	// Regular expressions
+	boi.registerRegexFunction("regex-match", 2, boiRegexMatch)
+	boi.registerRegexFunction("regex-find", 2, boiRegexFind)
+	boi.registerRegexFunction("regex-find-all", 2, boiRegexFindAll)
+	boi.registerRegexFunction("regex-submatch", 2, boiRegexSubmatch)
+	boi.registerRegexFunction("regex-replace", 3, boiRegexReplace)
+
+	// Time
+	boi.RegisterGoFunctionStruct("now", BoiFuncNow{boi, time.Second})
+	boi.RegisterGoFunctionStruct("now-nanos", BoiFuncNow{boi, time.Nanosecond})
+	boi.RegisterGoFunction("time-format", BoiFuncTimeFormat)
+	boi.RegisterGoFunction("time-parse", BoiFuncTimeParse)
+	boi.RegisterGoFunction("time-add", BoiFuncTimeAdd)
+	boi.RegisterGoFunction("time-sub", BoiFuncTimeSub)
+	boi.RegisterGoFunction("time-diff", BoiFuncTimeDiff)
+	boi.RegisterGoFunction("duration", BoiFuncDuration)
+	boi.RegisterGoFunction("duration-format", BoiFuncDurationFormat)
+	boi.RegisterGoFunctionStruct("sleep", BoiFuncSleep{boi})
+
+	// Randomness
+	boi.RegisterGoFunctionStruct("random", BoiFuncRandom{boi})
+	boi.RegisterGoFunctionStruct("random-int", BoiFuncRandomInt{boi})
+	boi.RegisterGoFunctionStruct("shuffle", BoiFuncShuffle{boi})
+
 	// Grey area (memes, also practical)
-	boi.RegisterGoFunction("declare", BoiFuncDeclare)
+	boi.RegisterGoFunctionStruct("declare", BoiFuncDeclare{boi})
 
 	// Memes
-	boi.RegisterGoFunction("IsEven", BoiFuncIsEven)
+	boi.RegisterGoFunctionStruct("IsEven", BoiFuncIsEven{boi})
 
 	return boi
 }
@@ -234,11 +594,117 @@ func (boi *BoiInterpreter) RegisterGoFunctionStruct(
 	boi.context.functions[fname] = adapter
 }
 
+// SetRandomSource makes the interpreter get all of its randomness from
+// the given source, which makes scripts using random, IsEven, declare,
+// etc. repeatable
+func (boi *BoiInterpreter) SetRandomSource(src rand.Source) {
+	boi.random = rand.New(src)
+}
+
+// SeedRandom is a shortcut for SetRandomSource with math/rand's source
+func (boi *BoiInterpreter) SeedRandom(seed int64) {
+	boi.SetRandomSource(rand.NewSource(seed))
+}
+
+// SetMaxCallDepth limits how deeply oh functions (and evals) can call
+// each other (including themselves) before the script fails with a stack
+// overflow error. A limit of 0 or less means no limit, which lets deep
+// recursion crash the interpreter instead.
+func (boi *BoiInterpreter) SetMaxCallDepth(depth int) {
+	boi.maxCallDepth = depth
+}
+
+// checkCallDepth returns a stack overflow error, naming what was about to
+// be called, if going one call deeper would be over the limit
+func (boi *BoiInterpreter) checkCallDepth(name string) error {
+	depth := len(boi.calls) + boi.evals
+	if boi.maxCallDepth > 0 && depth >= boi.maxCallDepth {
+		return fmt.Errorf(
+			"stack overflow in %s (max call depth is %d)",
+			name, boi.maxCallDepth,
+		)
+	}
+	return nil
+}
+
+// Load replaces the code to run, keeping all variables, functions and
+// settings. This is how interactive mode runs one line at a time.
+func (boi *BoiInterpreter) Load(input []byte) {
+	boi.input = input
+	boi.pos = 0
+	boi.context = boi.context.Root()
+}
+
+// Grant lets scripts do what a capability allows (ex: BoiCapFilesystem)
+func (boi *BoiInterpreter) Grant(capability IntyBoi) {
+	boi.capabilities |= capability
+}
+
+// Revoke takes back a capability given by Grant
+func (boi *BoiInterpreter) Revoke(capability IntyBoi) {
+	boi.capabilities &^= capability
+}
+
+// Can reports whether a capability was granted
+func (boi *BoiInterpreter) Can(capability IntyBoi) bool {
+	return boi.capabilities&capability == capability
+}
+
+// SetFilesystemRoot makes file paths relative to root, and keeps them
+// from leaving it. An empty root takes the restriction away.
+func (boi *BoiInterpreter) SetFilesystemRoot(root string) {
+	boi.fsRoot = root
+}
+
+// SetInput changes where scripts read input from, which is standard input
+// unless this is called
+func (boi *BoiInterpreter) SetInput(r io.Reader) {
+	if buffered, ok := r.(*bufio.Reader); ok {
+		boi.stdin = buffered
+		return
+	}
+	boi.stdin = bufio.NewReader(r)
+}
+
+// SetEnvironment gives scripts a made-up environment instead of the
+// process's real one; set-env changes the map that was passed in. A nil
+// map switches back to the real environment.
+func (boi *BoiInterpreter) SetEnvironment(env map[string]string) {
+	boi.env = env
+}
+
+// SetTimeout makes each Run stop with an error if it takes longer than
+// the given duration. Zero means there's no limit.
+func (boi *BoiInterpreter) SetTimeout(timeout time.Duration) {
+	boi.timeout = timeout
+}
+
+// checkDeadline returns an error once the current Run is out of time
+func (boi *BoiInterpreter) checkDeadline() error {
+	if !boi.deadline.IsZero() && !boi.clock.Now().Before(boi.deadline) {
+		return boi.timedOut()
+	}
+	return nil
+}
+
+// timedOut is the error for running out of time. Builtins that wait use
+// it directly once they've been cut short, as the clock they were given
+// might not have moved.
+func (boi *BoiInterpreter) timedOut() error {
+	return fmt.Errorf("timed out after %v", boi.timeout)
+}
+
+// SetLexicalScoping chooses between lexical and dynamic scoping for
+// functions defined after it's called. Dynamic scoping is the default.
+func (boi *BoiInterpreter) SetLexicalScoping(lexical bool) {
+	boi.lexicalScoping = lexical
+}
+
 func (boi *BoiInterpreter) subContext() *BoiContext {
 	ctx := &BoiContext{
 		map[string]BoiFunc{},
 		map[string]BoiVar{},
-		boi.context, nil,
+		boi.context, nil, nil, nil,
 	}
 	boi.context = ctx
 	return ctx
@@ -251,10 +717,93 @@ func (boi *BoiInterpreter) returnContext() error {
 		return errors.New("returned to nil context")
 	}
 	boi.context.returnCtx = returnCtx
+	boi.context.adopt(returnCtx.variables["exit"])
 	return nil
 }
 
+// boiAnonymousPrefix starts the names given to anonymous functions
+const boiAnonymousPrefix = "anonymous#"
+
+// boiAnonymousFunctions lets anonymous functions be called from any
+// scope, without keeping them alive. An anonymous function is held by
+// the contexts that can reach it: the one it was defined in (which a
+// lexical closure defined there keeps around too), one it was returned
+// to, and any with a variable it was stored in (see adopt). Once all of
+// those are gone, Go's garbage collector frees it, and it can't be
+// called anymore. This is what lets a function return a function, or
+// store one in a variable outside a loop, without every pass through a
+// loop leaving one behind.
+type boiAnonymousFunctions struct {
+	functions map[string]weak.Pointer[BoiStatementsFunction]
+
+	// sweepAt is how many names there can be before the names of freed
+	// functions are cleared out
+	sweepAt int
+}
+
+func (a *boiAnonymousFunctions) add(name string, f *BoiStatementsFunction) {
+	if len(a.functions) >= a.sweepAt {
+		for name, p := range a.functions {
+			if p.Value() == nil {
+				delete(a.functions, name)
+			}
+		}
+		a.sweepAt = 2*len(a.functions) + 64
+	}
+	a.functions[name] = weak.Make(f)
+}
+
+func (a *boiAnonymousFunctions) lookup(name string) (BoiFunc, bool) {
+	if a == nil {
+		return nil, false
+	}
+	f := a.functions[name].Value()
+	if f == nil {
+		return nil, false
+	}
+	return BoiGoFunctionAdapter{f, f.interpreter}, true
+}
+
+// adopt makes ctx hold on to the anonymous functions that the values
+// mention (including inside lists and dicts), so they last as long as
+// ctx does. Only the values are looked at, not the rest of the scope.
+func (ctx *BoiContext) adopt(values ...BoiVar) {
+	prefix := []byte(boiAnonymousPrefix)
+	for _, v := range values {
+		data := v.data
+		for i := bytes.Index(data, prefix); i >= 0; i = bytes.Index(data, prefix) {
+			data = data[i+len(prefix):]
+			digits := 0
+			for digits < len(data) && '0' <= data[digits] && data[digits] <= '9' {
+				digits++
+			}
+			name := boiAnonymousPrefix + string(data[:digits])
+			data = data[digits:]
+			if _, held := ctx.functions[name]; held {
+				continue
+			}
+			if f, exists := ctx.Lookup(name); exists {
+				ctx.functions[name] = f
+			}
+		}
+	}
+}
+
+// ownsAnonymous reports whether ctx holds any anonymous functions
+func (ctx *BoiContext) ownsAnonymous() bool {
+	for name := range ctx.functions {
+		if strings.HasPrefix(name, boiAnonymousPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (boi *BoiInterpreter) Run() error {
+	boi.deadline = time.Time{}
+	if boi.timeout > 0 {
+		boi.deadline = boi.clock.Now().Add(boi.timeout)
+	}
 	for {
 		if boi.whitespace() {
 			return nil
@@ -395,6 +944,22 @@ func (boi *BoiInterpreter) getStatement() (*BoiStatement, error) {
 		return &BoiStatement{
 			BoiOpLoop, tokens, statements,
 		}, nil
+	case "foreach":
+		boi.pos += 7
+		boi.noeof(boi.whitespace())
+		tokens, err := boi.GetTokens()
+		if err != nil {
+			return nil, err
+		}
+
+		statements, err := boi.GetStatements()
+		if err != nil {
+			return nil, err
+		}
+
+		return &BoiStatement{
+			BoiOpForeach, tokens, statements,
+		}, nil
 	case "oh":
 		fallthrough
 	case "OH":
@@ -473,10 +1038,10 @@ func (boi *BoiInterpreter) Call(identifier string, args []BoiVar) error {
 	*/
 }
 
-func (boi *BoiInterpreter) getValueOf(tok Token) (BoiVar, bool) {
+func (boi *BoiInterpreter) getValueOf(tok Token) (BoiVar, bool, error) {
 	switch tok.BoiType {
 	case BoiTokenValue:
-		return BoiVar{tok.BoiValue}, true
+		return BoiVar{tok.BoiValue}, true, nil
 	case BoiTokenVar:
 		context := boi.context
 		if tok.BoiSource == BoiSourceReturn {
@@ -485,7 +1050,7 @@ func (boi *BoiInterpreter) getValueOf(tok Token) (BoiVar, bool) {
 
 		if context == nil {
 			// TODO: Raise error if boi.context is strict context
-			return BoiVar{}, false
+			return BoiVar{}, false, nil
 		}
 
 		identifier := string(tok.BoiValue)
@@ -493,26 +1058,39 @@ func (boi *BoiInterpreter) getValueOf(tok Token) (BoiVar, bool) {
 		if !exists {
 			// TODO: Raise error if strict context
 		}
-		return value, exists
+		return value, exists, nil
 	case BoiTokenCall:
-		identifier := string(tok.Children[0].BoiValue)
-
-		args := []BoiVar{}
-		for _, tok := range tok.Children[1:] {
-			value, _ := boi.getValueOf(tok)
-			args = append(args, value)
+		// The function name can come from a variable (a function value)
+		values, err := boi.evalTokens(tok.Children)
+		if err != nil {
+			return BoiVar{}, false, err
 		}
+		identifier := string(values[0].data)
 
 		// Call statement
-		err := boi.Call(identifier, args)
+		err = boi.Call(identifier, values[1:])
 		if err != nil {
-			return BoiVar{}, false // TODO: Raise error
+			return BoiVar{}, false, err
 		}
 
 		output := boi.context.returnCtx.variables["exit"]
-		return BoiVar(output), true
+		return BoiVar(output), true, nil
+	}
+	return BoiVar{}, false, nil
+}
+
+// evalTokens gets the value of each token, stopping at the first function
+// call that fails
+func (boi *BoiInterpreter) evalTokens(tokens []Token) ([]BoiVar, error) {
+	values := []BoiVar{}
+	for _, tok := range tokens {
+		value, _, err := boi.getValueOf(tok)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
 	}
-	return BoiVar{}, false
+	return values, nil
 }
 
 func (boi *BoiInterpreter) eatToken() (Token, error) {
@@ -571,6 +1149,7 @@ func (boi *BoiInterpreter) eatToken() (Token, error) {
 			c := boi.input[boi.pos]
 			if literal {
 				value = append(value, c)
+				literal = false
 			} else {
 				if c == '\\' {
 					literal = true
@@ -592,6 +1171,7 @@ func (boi *BoiInterpreter) eatToken() (Token, error) {
 			c := boi.input[boi.pos]
 			if literal {
 				value = append(value, c)
+				literal = false
 			} else {
 				if c == '\\' {
 					literal = true
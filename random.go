@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// All of these use the interpreter's random source, so they can be made
+// repeatable with the -seed flag or BoiInterpreter.SeedRandom
+
+// BoiFuncRandom returns a decimal number from 0 up to (not including) 1
+type BoiFuncRandom struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncRandom) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("random doesn't take any parameters")
+	}
+	value := new(big.Rat).SetFloat64(f.interpreter.random.Float64())
+	return boiRatToVar(value), nil
+}
+
+// BoiFuncRandomInt returns an integer from 0 up to (not including) its
+// parameter, or from its first parameter up to its second
+type BoiFuncRandomInt struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncRandomInt) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return BoiVar{}, errors.New("random-int requires 1 or 2 parameters")
+	}
+	// With one parameter, the range starts from the 0 at the front
+	bounds := []*big.Int{new(big.Int)}
+	for _, arg := range args {
+		bound, err := boiVarToInt(arg)
+		if err != nil {
+			return BoiVar{}, fmt.Errorf("random-int: %v", err)
+		}
+		bounds = append(bounds, bound)
+	}
+	min, max := bounds[len(bounds)-2], bounds[len(bounds)-1]
+	if min.Cmp(max) >= 0 {
+		return BoiVar{}, errors.New("random-int: range is empty")
+	}
+	span := new(big.Int).Sub(max, min)
+	value := new(big.Int).Rand(f.interpreter.random, span)
+	return BoiVar{value.Add(value, min).Bytes()}, nil
+}
+
+// BoiFuncShuffle returns a list with the same elements in a random order
+type BoiFuncShuffle struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncShuffle) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("shuffle requires 1 parameter")
+	}
+	items, err := boiVarToList(args[0])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	f.interpreter.random.Shuffle(len(items), func(i, j int) {
+		items[i], items[j] = items[j], items[i]
+	})
+	return boiListToVar(items), nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+const boiRandomScript = `
+boi: r [random] boi
+boi: n [random-int [int 1000000]] boi
+boi: s [shuffle [list a b c d e f g h]] boi
+boi: e [IsEven [int 4]] boi
+ONE d boi
+`
+
+// boiRandomResults runs boiRandomScript with a seed and returns what each
+// of the random functions gave
+func boiRandomResults(t *testing.T, seed int64) map[string][]byte {
+	t.Helper()
+	boi, err := runBoi(t, boiRandomScript, func(boi *BoiInterpreter) {
+		boi.SeedRandom(seed)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string][]byte{}
+	for _, name := range []string{"r", "n", "s", "e", "d"} {
+		v, exists := boi.context.Get(name)
+		if !exists || len(v.data) == 0 {
+			t.Fatalf("%s wasn't set", name)
+		}
+		results[name] = v.data
+	}
+	return results
+}
+
+func TestSeedMakesRandomnessRepeatable(t *testing.T) {
+	first := boiRandomResults(t, 42)
+	second := boiRandomResults(t, 42)
+	for name, value := range first {
+		if !bytes.Equal(value, second[name]) {
+			t.Errorf("%s was %q, then %q with the same seed", name, value, second[name])
+		}
+	}
+
+	other := boiRandomResults(t, 43)
+	same := true
+	for name, value := range first {
+		same = same && bytes.Equal(value, other[name])
+	}
+	if same {
+		t.Error("a different seed gave the same results")
+	}
+}
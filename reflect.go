@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"sort"
+)
+
+// These functions let a script look at its own variables and functions.
+// They all start from the caller's context (context.parentCtx) and walk
+// up the chain the same way Get and Call do.
+
+// boiVisibleNames collects the names that a lookup from ctx could find,
+// using names to pick which map to read from each context
+func boiVisibleNames(
+	ctx *BoiContext,
+	names func(ctx *BoiContext) []string,
+	visible func(name string) bool,
+) BoiVar {
+	seen := map[string]bool{}
+	for tryContext := ctx; tryContext != nil; tryContext = tryContext.parentCtx {
+		for _, name := range names(tryContext) {
+			if !seen[name] && visible(name) {
+				seen[name] = true
+			}
+		}
+	}
+	sorted := []string{}
+	for name := range seen {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	output := []BoiVar{}
+	for _, name := range sorted {
+		output = append(output, BoiVar{[]byte(name)})
+	}
+	return boiListToVar(output)
+}
+
+// BoiFuncHasFunc returns true if calling the named function would find
+// something to call
+func BoiFuncHasFunc(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("has-func requires 1 parameter")
+	}
+	_, exists := context.parentCtx.Lookup(string(args[0].data))
+	return boiBool(exists), nil
+}
+
+// BoiFuncVars returns a sorted list of the variable names in scope
+func BoiFuncVars(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("vars doesn't take parameters")
+	}
+	ctx := context.parentCtx
+	return boiVisibleNames(
+		ctx,
+		func(c *BoiContext) []string {
+			names := []string{}
+			for name := range c.variables {
+				names = append(names, name)
+			}
+			return names
+		},
+		func(name string) bool {
+			// A function's caller's arguments are in the chain, but
+			// they aren't visible
+			_, exists := ctx.Get(name)
+			return exists
+		},
+	), nil
+}
+
+// BoiFuncFuncs returns a sorted list of the function names in scope
+func BoiFuncFuncs(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("funcs doesn't take parameters")
+	}
+	return boiVisibleNames(
+		context.parentCtx,
+		func(c *BoiContext) []string {
+			names := []string{}
+			for name := range c.functions {
+				names = append(names, name)
+			}
+			return names
+		},
+		func(name string) bool { return true },
+	), nil
+}
+
+// BoiFuncScopeDepth returns how many scopes there are between the caller
+// and the outermost one, so it's 0 at the top of a file
+func BoiFuncScopeDepth(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("scope-depth doesn't take parameters")
+	}
+	depth := 0
+	for ctx := context.parentCtx; ctx.parentCtx != nil; ctx = ctx.parentCtx {
+		depth++
+	}
+	return boiIndexToVar(depth), nil
+}
+
+// BoiFuncFuncName returns the name of the function that's running, or
+// an empty value outside of any function
+type BoiFuncFuncName struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncFuncName) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 0 {
+		return BoiVar{}, errors.New("func-name doesn't take parameters")
+	}
+	calls := f.interpreter.calls
+	if len(calls) == 0 {
+		return BoiVar{[]byte{}}, nil
+	}
+	return BoiVar{[]byte(calls[len(calls)-1])}, nil
+}
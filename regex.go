@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// boiRegexCacheSize is how many compiled patterns an interpreter keeps.
+// Once there are more, it starts over, so a script that builds lots of
+// different patterns can't use up all the memory.
+const boiRegexCacheSize = 256
+
+// compileRegex compiles a pattern, or reuses it if it was compiled before
+// (ex: in an earlier iteration of a bloop)
+func (boi *BoiInterpreter) compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, exists := boi.regexCache[pattern]; exists {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if boi.regexCache == nil || len(boi.regexCache) >= boiRegexCacheSize {
+		boi.regexCache = map[string]*regexp.Regexp{}
+	}
+	boi.regexCache[pattern] = re
+	return re, nil
+}
+
+// BoiFuncRegex runs one of the regex functions below. They all take a
+// pattern (using Go's syntax) and the text to search as their first two
+// parameters.
+type BoiFuncRegex struct {
+	interpreter *BoiInterpreter
+	name        string
+	params      int
+	run         func(
+		context *BoiContext, re *regexp.Regexp, text []byte, args []BoiVar,
+	) BoiVar
+}
+
+func (boi *BoiInterpreter) registerRegexFunction(
+	fname string,
+	params int,
+	run func(
+		context *BoiContext, re *regexp.Regexp, text []byte, args []BoiVar,
+	) BoiVar,
+) {
+	boi.RegisterGoFunctionStruct(fname, BoiFuncRegex{boi, fname, params, run})
+}
+
+func (f BoiFuncRegex) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != f.params {
+		return BoiVar{}, fmt.Errorf(
+			"%s requires %d parameters", f.name, f.params,
+		)
+	}
+	re, err := f.interpreter.compileRegex(string(args[0].data))
+	if err != nil {
+		return BoiVar{}, fmt.Errorf("%s: %v", f.name, err)
+	}
+	return f.run(context, re, args[1].data, args[2:]), nil
+}
+
+func boiRegexMatch(
+	context *BoiContext, re *regexp.Regexp, text []byte, args []BoiVar,
+) BoiVar {
+	return boiBool(re.Match(text))
+}
+
+// boiRegexFind returns the first match, and sets "exists" to say if there
+// was one
+func boiRegexFind(
+	context *BoiContext, re *regexp.Regexp, text []byte, args []BoiVar,
+) BoiVar {
+	loc := re.FindIndex(text)
+	context.variables["exists"] = boiBool(loc != nil)
+	if loc == nil {
+		return BoiVar{[]byte{}}
+	}
+	return BoiVar{append([]byte{}, text[loc[0]:loc[1]]...)}
+}
+
+// boiRegexFindAll returns a list of every match
+func boiRegexFindAll(
+	context *BoiContext, re *regexp.Regexp, text []byte, args []BoiVar,
+) BoiVar {
+	matches := []BoiVar{}
+	for _, match := range re.FindAll(text, -1) {
+		matches = append(matches, BoiVar{append([]byte{}, match...)})
+	}
+	return boiListToVar(matches)
+}
+
+// boiRegexSubmatch returns a list with the first match followed by what
+// each group in the pattern matched (empty if the group didn't take
+// part), and sets "exists" to say if there was a match
+func boiRegexSubmatch(
+	context *BoiContext, re *regexp.Regexp, text []byte, args []BoiVar,
+) BoiVar {
+	groups := re.FindSubmatch(text)
+	context.variables["exists"] = boiBool(groups != nil)
+	items := []BoiVar{}
+	for _, group := range groups {
+		items = append(items, BoiVar{append([]byte{}, group...)})
+	}
+	return boiListToVar(items)
+}
+
+// boiRegexReplace replaces every match. In the replacement, $1 (or ${1}
+// when a letter follows) stands for what the first group matched, and
+// so on.
+func boiRegexReplace(
+	context *BoiContext, re *regexp.Regexp, text []byte, args []BoiVar,
+) BoiVar {
+	return BoiVar{re.ReplaceAll(text, args[0].data)}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// boiWithCacheCheck adds a check function that calls check with the
+// interpreter, so tests can look at it from inside a loop
+func boiWithCacheCheck(check func(*BoiInterpreter)) func(*BoiInterpreter) {
+	return func(boi *BoiInterpreter) {
+		boi.RegisterGoFunction("check", func(
+			context *BoiContext, args []BoiVar,
+		) (BoiVar, error) {
+			check(boi)
+			return BoiVar{}, nil
+		})
+	}
+}
+
+func TestRegexCacheReusedInLoop(t *testing.T) {
+	seen := []*regexp.Regexp{}
+	boi, err := runBoi(t, `
+boi: i [int 0] boi
+bloop < boi:i [int 10] boi
+    boi! regex-match "^bo+i$" booooi boi
+    boi! check boi
+    boi: i [+ boi:i [int 1]] boi
+BOI
+`, boiWithCacheCheck(func(boi *BoiInterpreter) {
+		seen = append(seen, boi.regexCache["^bo+i$"])
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 10 {
+		t.Fatalf("loop ran %d times, expected 10", len(seen))
+	}
+	for i, re := range seen {
+		if re == nil || re != seen[0] {
+			t.Errorf("pattern in iteration %d wasn't the cached one", i)
+		}
+	}
+	if len(boi.regexCache) != 1 {
+		t.Errorf("cache has %d patterns, expected 1", len(boi.regexCache))
+	}
+}
+
+func TestRegexCacheStartsOverWhenFull(t *testing.T) {
+	biggest := 0
+	boi, err := runBoi(t, `
+boi: i [int 0] boi
+bloop < boi:i [int 300] boi
+    boi! regex-match [nyan "^x" [dec boi:i] "$"] x1 boi
+    boi! check boi
+    boi: i [+ boi:i [int 1]] boi
+BOI
+`, boiWithCacheCheck(func(boi *BoiInterpreter) {
+		if len(boi.regexCache) > biggest {
+			biggest = len(boi.regexCache)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if biggest != boiRegexCacheSize {
+		t.Errorf("cache grew to %d patterns, expected %d", biggest, boiRegexCacheSize)
+	}
+	if expected := 300 - boiRegexCacheSize; len(boi.regexCache) != expected {
+		t.Errorf("cache has %d patterns, expected %d", len(boi.regexCache), expected)
+	}
+}
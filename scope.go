@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BoiFuncScoping switches between "lexical" and "dynamic" scoping for
+// functions defined after it's called. Putting it at the top of a file
+// makes the whole file lexically scoped.
+type BoiFuncScoping struct {
+	interpreter *BoiInterpreter
+}
+
+func (f BoiFuncScoping) Run(
+	context *BoiContext, args []BoiVar,
+) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("scoping requires 1 parameter")
+	}
+	switch mode := string(args[0].data); mode {
+	case "lexical":
+		f.interpreter.SetLexicalScoping(true)
+	case "dynamic":
+		f.interpreter.SetLexicalScoping(false)
+	default:
+		return BoiVar{}, fmt.Errorf(
+			"scoping must be lexical or dynamic, not '%s'", mode,
+		)
+	}
+	return args[0], nil
+}
+
+// BoiFuncLocal is like set, but always creates the variable in the
+// current scope, even if a parent scope has one with the same name
+func BoiFuncLocal(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("local requires 2 parameters")
+	}
+	context.parentCtx.variables[string(args[0].data)] = args[1]
+	context.parentCtx.adopt(args[1])
+	return args[1], nil
+}
+
+// BoiFuncGlobal is like set, but always uses the outermost scope
+func BoiFuncGlobal(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("global requires 2 parameters")
+	}
+	root := context.Root()
+	root.variables[string(args[0].data)] = args[1]
+	root.adopt(args[1])
+	return args[1], nil
+}
+
+// BoiFuncUnset deletes the variable that the name currently refers to,
+// which makes any variable it was hiding visible again. It returns
+// whether there was anything to delete.
+func BoiFuncUnset(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("unset requires 1 parameter")
+	}
+	return boiBool(context.parentCtx.Unset(string(args[0].data))), nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"math/big"
 )
 
 const (
@@ -9,6 +10,7 @@ const (
 	BoiOpIf      = 2
 	BoiOpLoop    = 3
 	BoiOpFuncDef = 4
+	BoiOpForeach = 5
 )
 
 type BoiStatement struct {
@@ -31,17 +33,38 @@ func NewCallStatement(fname string, tokens []Token) *BoiStatement {
 	}
 }
 
+// evalCondition makes the call described by the tokens of a boi? or bloop
+// statement, and reports whether it returned something true
+func (boi *BoiInterpreter) evalCondition(tokens []Token) (bool, error) {
+	args, err := boi.evalTokens(tokens)
+	if err != nil {
+		return false, err
+	}
+
+	// Call statement
+	identifier := string(args[0].data)
+	err = boi.Call(identifier, args[1:])
+	if err != nil {
+		return false, err
+	}
+
+	exitVar, exists := boi.context.returnCtx.variables["exit"]
+	return exists && exitVar.IsTrue(), nil
+}
+
 func (boi *BoiInterpreter) ExecStmt(stmt *BoiStatement) error {
+	if err := boi.checkDeadline(); err != nil {
+		return err
+	}
 	switch stmt.Operation {
 	case BoiOpCall:
 		if len(stmt.Tokens) < 1 {
 			return fmt.Errorf("boi! must have at least one token")
 		}
 
-		args := []BoiVar{}
-		for _, tok := range stmt.Tokens {
-			value, _ := boi.getValueOf(tok)
-			args = append(args, value)
+		args, err := boi.evalTokens(stmt.Tokens)
+		if err != nil {
+			return err
 		}
 
 		identifier := string(args[0].data)
@@ -51,29 +74,12 @@ func (boi *BoiInterpreter) ExecStmt(stmt *BoiStatement) error {
 			return fmt.Errorf("boi? must have at least one token")
 		}
 
-		args := []BoiVar{}
-		for _, tok := range stmt.Tokens {
-			value, _ := boi.getValueOf(tok)
-			args = append(args, value)
-		}
-
-		// Call statement
-		identifier := string(args[0].data)
-		err := boi.Call(identifier, args[1:])
+		// Execute subsequent statements if output is true
+		isTrue, err := boi.evalCondition(stmt.Tokens)
 		if err != nil {
 			return err
 		}
-
-		// Execute subsequent statements if output is true
-		exitVar, exists := boi.context.returnCtx.variables["exit"]
-
-		// Check for falsy values
-		switch true {
-		case !exists:
-			fallthrough
-		case len(exitVar.data) == 0:
-			fallthrough
-		case string(exitVar.data) == "false":
+		if !isTrue {
 			// Falsey value encountered - do not execute aggregate statements
 			return nil
 		}
@@ -100,30 +106,18 @@ func (boi *BoiInterpreter) ExecStmt(stmt *BoiStatement) error {
 
 		for continueLoop {
 
-			// Recalculate arguments
-			args := []BoiVar{}
-			for _, tok := range stmt.Tokens {
-				value, _ := boi.getValueOf(tok)
-				args = append(args, value)
+			// An empty loop body wouldn't check the deadline
+			if err := boi.checkDeadline(); err != nil {
+				return err
 			}
 
-			// Call statement
-			identifier := string(args[0].data)
-			err := boi.Call(identifier, args[1:])
+			// Recalculate arguments and call again
+			isTrue, err := boi.evalCondition(stmt.Tokens)
 			if err != nil {
 				return err
 			}
 
-			// Execute subsequent statements if output is true
-			exitVar, exists := boi.context.returnCtx.variables["exit"]
-
-			// Check for falsy values
-			switch true {
-			case !exists:
-				fallthrough
-			case len(exitVar.data) == 0:
-				fallthrough
-			case string(exitVar.data) == "false":
+			if !isTrue {
 				// Falsey value encountered - do not execute aggregate statements
 				continueLoop = false
 			}
@@ -153,20 +147,128 @@ func (boi *BoiInterpreter) ExecStmt(stmt *BoiStatement) error {
 			}
 		}
 		return nil
+	case BoiOpForeach:
+		if len(stmt.Tokens) < 2 {
+			return fmt.Errorf("foreach must have a variable name and a value")
+		}
+
+		args, err := boi.evalTokens(stmt.Tokens)
+		if err != nil {
+			return err
+		}
+		name := string(args[0].data)
+
+		// Each iteration gets its own scope with the loop variable in it
+		iterate := func(value BoiVar) error {
+			// Checked here too, as an empty body runs no statements
+			if err := boi.checkDeadline(); err != nil {
+				return err
+			}
+			ctx := boi.subContext()
+			defer boi.returnContext()
+			ctx.variables[name] = value
+
+			// Execute aggregate statements
+			for _, stmt := range stmt.Children {
+				if stmt != nil {
+					err := boi.ExecStmt(stmt)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
+		switch len(args) {
+		case 2:
+			// Loop over a list's elements, or else a value's bytes
+			if boiIsList(args[1]) {
+				items, err := boiVarToList(args[1])
+				if err != nil {
+					return err
+				}
+				for _, item := range items {
+					if err := iterate(item); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			for _, c := range args[1].data {
+				if err := iterate(BoiVar{[]byte{c}}); err != nil {
+					return err
+				}
+			}
+			return nil
+		case 3:
+			// Loop over integers from the first value up to the second
+			i, err := boiVarToInt(args[1])
+			if err != nil {
+				return fmt.Errorf("foreach: %v", err)
+			}
+			end, err := boiVarToInt(args[2])
+			if err != nil {
+				return fmt.Errorf("foreach: %v", err)
+			}
+			for ; i.Cmp(end) < 0; i.Add(i, big.NewInt(1)) {
+				if err := iterate(BoiVar{i.Bytes()}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return fmt.Errorf("foreach takes a value, or a start and an end")
 	case BoiOpFuncDef:
 
 		var identifier string
+		params := []BoiParam{}
 		if len(stmt.Tokens) < 1 {
 			identifier = ""
 		} else {
-			identifierBoi, _ := boi.getValueOf(stmt.Tokens[0])
-			identifier = string(identifierBoi.data)
+			values, err := boi.evalTokens(stmt.Tokens)
+			if err != nil {
+				return err
+			}
+			identifier = string(values[0].data)
+
+			// Anything after the name declares parameters
+			if params, err = ParseBoiParams(values[1:]); err != nil {
+				return fmt.Errorf("function %s: %v", identifier, err)
+			}
+		}
+
+		var closure *BoiContext
+		if boi.lexicalScoping {
+			closure = boi.context
 		}
 
-		boi.RegisterGoFunctionStruct(
-			identifier,
-			NewBoiStatementsFunction(stmt.Children, boi),
+		if identifier != "" {
+			boi.RegisterGoFunctionStruct(
+				identifier,
+				NewBoiStatementsFunction(
+					identifier, params, stmt.Children, boi, closure,
+				),
+			)
+			return nil
+		}
+
+		// An anonymous function gets a unique name, which is the function
+		// value, and it's left in ret:exit. The current scope holds on to
+		// it, and it can be called from anywhere until nothing does (see
+		// boiAnonymousFunctions).
+		boi.anonymousCount++
+		identifier = fmt.Sprintf("%s%d", boiAnonymousPrefix, boi.anonymousCount)
+		anonymous := NewBoiStatementsFunction(
+			identifier, params, stmt.Children, boi, closure,
 		)
+		boi.context.functions[identifier] = BoiGoFunctionAdapter{anonymous, boi}
+		boi.context.Root().anonymous.add(identifier, anonymous)
+		boi.context.returnCtx = &BoiContext{
+			map[string]BoiFunc{},
+			map[string]BoiVar{"exit": BoiVar{[]byte(identifier)}},
+			nil, nil, nil, nil,
+		}
 
 		return nil
 	}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Functions in this file treat values as plain byte arrays. Positions,
+// lengths and counts are integer values, so they work with int, +, dec
+// and friends.
+
+func BoiFuncLen(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("len requires 1 parameter")
+	}
+	return boiIndexToVar(len(args[0].data)), nil
+}
+
+// BoiFuncSlice returns the bytes from a start position up to (but not
+// including) an optional end position.
+func BoiFuncSlice(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return BoiVar{}, errors.New("slice requires 2 or 3 parameters")
+	}
+	data := args[0].data
+	start, err := boiVarToIndex(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	end := len(data)
+	if len(args) > 2 {
+		if end, err = boiVarToIndex(args[2]); err != nil {
+			return BoiVar{}, err
+		}
+	}
+	if start > end || end > len(data) {
+		return BoiVar{}, fmt.Errorf(
+			"slice [%d:%d] out of range for length %d", start, end, len(data),
+		)
+	}
+	return BoiVar{append([]byte{}, data[start:end]...)}, nil
+}
+
+// BoiFuncIndexOf returns the position of the first occurrence of the
+// second parameter in the first. Since 0 is a valid position, whether
+// or not it was found is reported through the "exists" variable.
+func BoiFuncIndexOf(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("index-of requires 2 parameters")
+	}
+	i := bytes.Index(args[0].data, args[1].data)
+	if i < 0 {
+		context.variables["exists"] = BoiVar{[]byte("false")}
+		return BoiVar{}, nil
+	}
+	context.variables["exists"] = BoiVar{[]byte("true")}
+	return boiIndexToVar(i), nil
+}
+
+// BoiFuncSplit splits the first parameter around each occurrence of the
+// second, and returns the pieces as a list.
+func BoiFuncSplit(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("split requires 2 parameters")
+	}
+	parts := []BoiVar{}
+	for _, part := range bytes.Split(args[0].data, args[1].data) {
+		parts = append(parts, BoiVar{part})
+	}
+	return boiListToVar(parts), nil
+}
+
+// BoiFuncJoin strings together all parameters after the first, with the
+// first parameter in between each of them. If the only other parameter is
+// a list, its elements are joined instead.
+func BoiFuncJoin(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) < 1 {
+		return BoiVar{}, errors.New("join requires at least 1 parameter")
+	}
+	values := args[1:]
+	if len(values) == 1 && boiIsList(values[0]) {
+		var err error
+		if values, err = boiVarToList(values[0]); err != nil {
+			return BoiVar{}, err
+		}
+	}
+	parts := [][]byte{}
+	for _, value := range values {
+		parts = append(parts, value.data)
+	}
+	return BoiVar{bytes.Join(parts, args[0].data)}, nil
+}
+
+func BoiFuncReplace(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 3 {
+		return BoiVar{}, errors.New("replace requires 3 parameters")
+	}
+	return BoiVar{
+		bytes.ReplaceAll(args[0].data, args[1].data, args[2].data),
+	}, nil
+}
+
+// boiChangeCase copies data, moving the letters from..from+25 to
+// to..to+25. Only ASCII letters change; every other byte stays as it is.
+func boiChangeCase(data []byte, from, to byte) []byte {
+	output := make([]byte, len(data))
+	for i, b := range data {
+		if b >= from && b <= from+'z'-'a' {
+			b = b - from + to
+		}
+		output[i] = b
+	}
+	return output
+}
+
+func BoiFuncUpper(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("upper requires 1 parameter")
+	}
+	return BoiVar{boiChangeCase(args[0].data, 'a', 'A')}, nil
+}
+
+func BoiFuncLower(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("lower requires 1 parameter")
+	}
+	return BoiVar{boiChangeCase(args[0].data, 'A', 'a')}, nil
+}
+
+// BoiFuncTrim removes whitespace from both ends of a value, or any of the
+// bytes in the second parameter if one is given.
+func BoiFuncTrim(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	switch len(args) {
+	case 1:
+		return BoiVar{bytes.TrimSpace(args[0].data)}, nil
+	case 2:
+		return BoiVar{bytes.Trim(args[0].data, string(args[1].data))}, nil
+	}
+	return BoiVar{}, errors.New("trim requires 1 or 2 parameters")
+}
+
+// boiMaxRepeatSize is the biggest value repeat will make (1GB), so a
+// script can't use up all the memory with one call
+const boiMaxRepeatSize = 1 << 30
+
+func BoiFuncRepeat(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("repeat requires 2 parameters")
+	}
+	count, err := boiVarToIndex(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	// Dividing instead of multiplying means this can't overflow
+	if size := len(args[0].data); size > 0 && count > boiMaxRepeatSize/size {
+		return BoiVar{}, fmt.Errorf(
+			"repeat: result would be over %d bytes", boiMaxRepeatSize,
+		)
+	}
+	return BoiVar{bytes.Repeat(args[0].data, count)}, nil
+}
+
+func BoiFuncReverse(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("reverse requires 1 parameter")
+	}
+	data := args[0].data
+	output := make([]byte, len(data))
+	for i, c := range data {
+		output[len(data)-1-i] = c
+	}
+	return BoiVar{output}, nil
+}
+
+// BoiFuncByteAt returns the byte at a position. A single byte is also an
+// integer value, so the result can be used with dec, +, etc.
+func BoiFuncByteAt(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 2 {
+		return BoiVar{}, errors.New("byte-at requires 2 parameters")
+	}
+	i, err := boiVarToIndex(args[1])
+	if err != nil {
+		return BoiVar{}, err
+	}
+	if i >= len(args[0].data) {
+		return BoiVar{}, fmt.Errorf(
+			"byte-at %d out of range for length %d", i, len(args[0].data),
+		)
+	}
+	return BoiVar{[]byte{args[0].data[i]}}, nil
+}
+
+// BoiFuncFromByte turns an integer from 0 to 255 into exactly one byte.
+// This matters for 0, which is otherwise an empty value.
+func BoiFuncFromByte(context *BoiContext, args []BoiVar) (BoiVar, error) {
+	if len(args) != 1 {
+		return BoiVar{}, errors.New("from-byte requires 1 parameter")
+	}
+	value, err := boiVarToIndex(args[0])
+	if err != nil || value > 255 {
+		return BoiVar{}, errors.New("from-byte requires a value from 0 to 255")
+	}
+	return BoiVar{[]byte{byte(value)}}, nil
+}
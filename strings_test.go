@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestCaseOnlyChangesASCIILetters(t *testing.T) {
+	boi, err := runBoi(t, `
+boi: up [upper "abc XYZ@[{"] boi
+boi: down [lower "abc XYZ@[{"] boi
+boi: high [hex-encode [upper [from-byte [int 200]]]] boi
+boi: number [hex-encode [lower [int 1000]]] boi
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"up":     "ABC XYZ@[{",
+		"down":   "abc xyz@[{",
+		"high":   "c8",
+		"number": "03e8",
+	}
+	for name, value := range expected {
+		if v, _ := boi.context.Get(name); string(v.data) != value {
+			t.Errorf("%s is %q, expected %q", name, v.data, value)
+		}
+	}
+}